@@ -0,0 +1,240 @@
+package madek
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ChangedSince will compile and return the media entries of the specified
+// collection that have been created, updated or edited after the provided
+// time. Unchanged media entries are only fetched, not compiled.
+func (c *Client) ChangedSince(collectionID string, since time.Time) ([]*MediaEntry, error) {
+	// get media entry ids
+	mediaEntryIds, err := c.mediaEntryIDs(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// compile changed media entries
+	return c.compileMediaEntries(mediaEntryIds, func(id, doc string) (bool, error) {
+		modified, err := modifiedAt(doc)
+		return modified.After(since), err
+	})
+}
+
+// RefreshCollection will update the provided compiled collection. Only the
+// meta data and media entries that changed since the collection has been
+// compiled are recompiled. Media entries that have been added or removed from
+// the collection are compiled or dropped. If permissions are included, they
+// are fetched again for the collection and all unchanged media entries, as
+// permission changes do not affect the update times. Unchanged media entries
+// are replaced by copies in that case.
+func (c *Client) RefreshCollection(coll *Collection) error {
+	// fetch collection
+	collStr, err := c.fetchByID("collection", coll.ID)
+	if err != nil {
+		return err
+	}
+
+	// get modification time
+	modified, err := modifiedAt(collStr)
+	if err != nil {
+		return err
+	}
+
+	// recompile meta data if changed
+	if coll.MetaData == nil || modified.After(coll.ModifiedAt()) {
+		// get meta data url
+		metaDataURL, err := c.followOrExpand(collStr, "meta-data", "collection-meta-data", map[string]string{"id": coll.ID})
+		if err != nil {
+			return err
+		}
+
+		// compile meta data
+		coll.MetaData, err = c.CompileMetaData(metaDataURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	// update times
+	coll.UpdatedAt, coll.MetaDataUpdatedAt, coll.EditSessionUpdatedAt, err = parseUpdateTimes(collStr)
+	if err != nil {
+		return err
+	}
+
+	// refresh permissions
+	if c.IncludePermissions {
+		coll.Permissions, err = c.GetPermissions(CollectionKind, coll.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// get media entry ids
+	mediaEntryIds, err := c.mediaEntryIDs(coll.ID)
+	if err != nil {
+		return err
+	}
+
+	// index current media entries
+	current := map[string]*MediaEntry{}
+	for _, entry := range coll.MediaEntries {
+		current[entry.ID] = entry
+	}
+
+	// compile new and changed media entries
+	compiled, err := c.compileMediaEntries(mediaEntryIds, func(id, doc string) (bool, error) {
+		entry, ok := current[id]
+		if !ok {
+			return true, nil
+		}
+		modified, err := modifiedAt(doc)
+		return !modified.Equal(entry.ModifiedAt()), err
+	})
+	if err != nil {
+		return err
+	}
+
+	// index compiled media entries
+	recompiled := map[string]bool{}
+	for _, entry := range compiled {
+		current[entry.ID] = entry
+		recompiled[entry.ID] = true
+	}
+
+	// refresh permissions of unchanged media entries
+	if c.IncludePermissions {
+		for _, id := range mediaEntryIds {
+			// skip recompiled media entries
+			if recompiled[id] {
+				continue
+			}
+
+			// fetch permissions
+			perms, err := c.GetPermissions(MediaEntryKind, id)
+			if err != nil {
+				return err
+			}
+
+			// replace media entry
+			entry := *current[id]
+			entry.Permissions = perms
+			current[id] = &entry
+		}
+	}
+
+	// set media entries
+	coll.MediaEntries = nil
+	for _, id := range mediaEntryIds {
+		coll.MediaEntries = append(coll.MediaEntries, current[id])
+	}
+
+	// sort media entries
+	sort.Slice(coll.MediaEntries, func(i, j int) bool {
+		return coll.MediaEntries[i].ID < coll.MediaEntries[j].ID
+	})
+
+	return nil
+}
+
+func (c *Client) mediaEntryIDs(collectionID string) ([]string, error) {
+	// get media entries url
+	mediaEntriesURL, err := c.Expand("media-entries", map[string]string{
+		"collection_id": collectionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Paginate(mediaEntriesURL, "media-entries", 0).IDs()
+}
+
+// compileMediaEntries will concurrently fetch the specified media entries and
+// compile the ones that pass the optional filter. The result is sorted by id.
+func (c *Client) compileMediaEntries(ids []string, filter func(id, doc string) (bool, error)) ([]*MediaEntry, error) {
+	// prepare wait group
+	var wg sync.WaitGroup
+	wg.Add(len(ids))
+
+	// prepare result
+	asyncErrors := make(chan error, len(ids))
+	mediaEntries := make(chan *MediaEntry, len(ids))
+
+	// check media entries concurrently
+	for _, entryID := range ids {
+		go func(id string) {
+			defer wg.Done()
+
+			// fetch media entry
+			mediaEntryStr, err := c.fetchByID("media-entry", id)
+			if err != nil {
+				asyncErrors <- err
+				return
+			}
+
+			// check filter
+			if filter != nil {
+				ok, err := filter(id, mediaEntryStr)
+				if err != nil {
+					asyncErrors <- err
+					return
+				} else if !ok {
+					return
+				}
+			}
+
+			// compile media entry
+			mediaEntry, err := c.compileMediaEntry(id, mediaEntryStr)
+			if err != nil {
+				asyncErrors <- err
+				return
+			}
+
+			// send media entry
+			mediaEntries <- mediaEntry
+		}(entryID)
+	}
+
+	// await done
+	wg.Wait()
+	close(asyncErrors)
+	close(mediaEntries)
+
+	// check errors
+	if len(asyncErrors) > 0 {
+		return nil, <-asyncErrors
+	}
+
+	// collect media entries
+	var list []*MediaEntry
+	for mediaEntry := range mediaEntries {
+		list = append(list, mediaEntry)
+	}
+
+	// sort media entries
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	return list, nil
+}
+
+func modifiedAt(doc string) (time.Time, error) {
+	// parse creation time
+	created, err := time.Parse(time.RFC3339, gjson.Get(doc, "created_at").Str)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// parse update times
+	updated, metaData, editSession, err := parseUpdateTimes(doc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return latestTime(created, timeValue(updated), timeValue(metaData), timeValue(editSession)), nil
+}
@@ -0,0 +1,42 @@
+package madek
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedSince(t *testing.T) {
+	routes := fakeMadek("/api/")
+	server := fakeAPI(t, routes)
+	client := NewClient(server.URL, "", "")
+
+	coll, err := client.CompileCollection("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2016, 6, 1, 10, 0, 0, 0, time.UTC), *coll.UpdatedAt)
+	assert.Equal(t, time.Date(2016, 6, 1, 10, 0, 0, 0, time.UTC), coll.ModifiedAt())
+	assert.Equal(t, time.Date(2016, 5, 26, 9, 55, 1, 0, time.UTC), *coll.MediaEntries[0].MetaDataUpdatedAt)
+	assert.Equal(t, time.Date(2016, 5, 27, 10, 49, 49, 0, time.UTC), coll.MediaEntries[1].ModifiedAt())
+
+	list, err := client.ChangedSince("c1", time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	list, err = client.ChangedSince("c1", time.Date(2016, 5, 26, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "e2", list[0].ID)
+	assert.Equal(t, "Video", list[0].MetaData.Title)
+
+	list, err = client.ChangedSince("c1", time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+	routes["/api/entries/e2"] = strings.Replace(routes["/api/entries/e2"], "2016-05-27T10:49:49Z", "yesterday", 1)
+	_, err = client.ChangedSince("c1", time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+
+	err = client.RefreshCollection(coll)
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+package madek
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Citation holds the bibliographic data of a media entry or collection.
+type Citation struct {
+	ID        string
+	Type      string
+	Title     string
+	Authors   []*Author
+	Year      string
+	Publisher string
+	Keywords  []string
+	URL       string
+}
+
+// A CSLItem is a CSL-JSON item.
+type CSLItem struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title,omitempty"`
+	Author    []*CSLName `json:"author,omitempty"`
+	Issued    *CSLDate   `json:"issued,omitempty"`
+	Publisher string     `json:"publisher,omitempty"`
+	Keyword   string     `json:"keyword,omitempty"`
+	URL       string     `json:"URL,omitempty"`
+}
+
+// A CSLName is the name of a CSL-JSON author.
+type CSLName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+// A CSLDate is a CSL-JSON date.
+type CSLDate struct {
+	DateParts [][]int `json:"date-parts,omitempty"`
+	Raw       string  `json:"raw,omitempty"`
+}
+
+// Citation will return the citation of the media entry. The web page on the
+// Madek instance with the provided address is used as URL if the address is
+// not empty. The type is derived from the file type e.g. "image".
+func (e *MediaEntry) Citation(address string) *Citation {
+	// prepare citation
+	citation := newCitation(e.ID, e.MetaData)
+	citation.Type = "document"
+	if i := strings.IndexByte(e.FileType, '/'); i >= 0 {
+		switch typ := e.FileType[:i]; typ {
+		case "image", "video", "audio":
+			citation.Type = typ
+		}
+	}
+
+	// set url
+	if address != "" {
+		citation.URL = WebURL(address, MediaEntryKind, e.ID)
+	}
+
+	return citation
+}
+
+// Citation will return the citation of the collection. The web page on the
+// Madek instance with the provided address is used as URL if the address is
+// not empty.
+func (c *Collection) Citation(address string) *Citation {
+	// prepare citation
+	citation := newCitation(c.ID, c.MetaData)
+	citation.Type = "collection"
+
+	// set url
+	if address != "" {
+		citation.URL = WebURL(address, CollectionKind, c.ID)
+	}
+
+	return citation
+}
+
+func newCitation(id string, md *MetaData) *Citation {
+	// prepare citation
+	citation := &Citation{
+		ID: id,
+	}
+
+	// add meta data
+	if md != nil {
+		citation.Title = md.Title
+		citation.Authors = md.Authors
+		citation.Year = md.Year
+		citation.Publisher = strings.Join(groupNames(md.Affiliation), ", ")
+		citation.Keywords = md.Keywords
+	}
+
+	return citation
+}
+
+// BibTeX will return the citation as a BibTeX @misc entry.
+func (c *Citation) BibTeX() string {
+	// prepare fields
+	var names []string
+	for _, author := range c.Authors {
+		if author.FirstName == "" || author.LastName == "" {
+			names = append(names, "{"+bibEscape(author.Name())+"}")
+		} else {
+			names = append(names, bibEscape(citationName(author)))
+		}
+	}
+	fields := [][2]string{
+		{"title", bibEscape(c.Title)},
+		{"author", strings.Join(names, " and ")},
+		{"year", bibEscape(c.Year)},
+		{"publisher", bibEscape(c.Publisher)},
+		{"keywords", bibEscape(strings.Join(c.Keywords, ", "))},
+		{"howpublished", urlCommand(c.URL)},
+		{"url", c.URL},
+	}
+
+	// write entry
+	var b strings.Builder
+	fmt.Fprintf(&b, "@misc{madek:%s", c.ID)
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(&b, ",\n  %s = {%s}", field[0], field[1])
+		}
+	}
+	b.WriteString("\n}\n")
+
+	return b.String()
+}
+
+// RIS will return the citation in the RIS format.
+func (c *Citation) RIS() string {
+	// get type
+	typ := map[string]string{
+		"image":      "ART",
+		"video":      "VIDEO",
+		"audio":      "SOUND",
+		"collection": "GEN",
+	}[c.Type]
+	if typ == "" {
+		typ = "GEN"
+	}
+
+	// write tags
+	var b strings.Builder
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s  - %s\r\n", name, value)
+		}
+	}
+	tag("TY", typ)
+	tag("ID", c.ID)
+	tag("TI", c.Title)
+	for _, author := range c.Authors {
+		tag("AU", citationName(author))
+	}
+	if year := yearPattern.FindString(c.Year); year != "" {
+		tag("PY", year)
+	} else {
+		tag("PY", c.Year)
+	}
+	tag("PB", c.Publisher)
+	for _, keyword := range c.Keywords {
+		tag("KW", keyword)
+	}
+	tag("UR", c.URL)
+	b.WriteString("ER  - \r\n")
+
+	return b.String()
+}
+
+// CSL will return the citation as a CSL-JSON item.
+func (c *Citation) CSL() *CSLItem {
+	// get type
+	typ := map[string]string{
+		"image":      "graphic",
+		"video":      "motion_picture",
+		"audio":      "song",
+		"collection": "collection",
+	}[c.Type]
+	if typ == "" {
+		typ = "document"
+	}
+
+	// prepare item
+	item := &CSLItem{
+		ID:        c.ID,
+		Type:      typ,
+		Title:     c.Title,
+		Publisher: c.Publisher,
+		Keyword:   strings.Join(c.Keywords, ", "),
+		URL:       c.URL,
+	}
+
+	// add authors
+	for _, author := range c.Authors {
+		if author.FirstName == "" || author.LastName == "" {
+			item.Author = append(item.Author, &CSLName{Literal: author.Name()})
+		} else {
+			item.Author = append(item.Author, &CSLName{Family: author.LastName, Given: author.FirstName})
+		}
+	}
+
+	// add date
+	if year, err := strconv.Atoi(c.Year); err == nil {
+		item.Issued = &CSLDate{DateParts: [][]int{{year}}}
+	} else if c.Year != "" {
+		item.Issued = &CSLDate{Raw: c.Year}
+	}
+
+	return item
+}
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+var bibReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+func bibEscape(str string) string {
+	return bibReplacer.Replace(str)
+}
+
+func urlCommand(url string) string {
+	// check url
+	if url == "" {
+		return ""
+	}
+
+	return `\url{` + url + `}`
+}
+
+func citationName(author *Author) string {
+	// check parts
+	if author.FirstName == "" || author.LastName == "" {
+		return author.Name()
+	}
+
+	return author.LastName + ", " + author.FirstName
+}
@@ -0,0 +1,67 @@
+package madek
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCitation(t *testing.T) {
+	entry := &MediaEntry{
+		ID:       "e1",
+		FileType: "image/jpeg",
+		MetaData: &MetaData{
+			Title:       "Form & Function_1",
+			Authors:     []*Author{{FirstName: "Jane", LastName: "Doe"}, {LastName: "Studio X"}},
+			Year:        "2016",
+			Keywords:    []string{"Design"},
+			Affiliation: []*Group{{Name: "Department"}},
+		},
+	}
+
+	citation := entry.Citation("https://madek.example.com")
+	assert.Equal(t, "image", citation.Type)
+	assert.Equal(t, "https://madek.example.com/entries/e1", citation.URL)
+
+	assert.Equal(t, `@misc{madek:e1,
+  title = {Form \& Function\_1},
+  author = {Doe, Jane and {Studio X}},
+  year = {2016},
+  publisher = {Department},
+  keywords = {Design},
+  howpublished = {\url{https://madek.example.com/entries/e1}},
+  url = {https://madek.example.com/entries/e1}
+}
+`, citation.BibTeX())
+
+	assert.Equal(t, "TY  - ART\r\n"+
+		"ID  - e1\r\n"+
+		"TI  - Form & Function_1\r\n"+
+		"AU  - Doe, Jane\r\n"+
+		"AU  - Studio X\r\n"+
+		"PY  - 2016\r\n"+
+		"PB  - Department\r\n"+
+		"KW  - Design\r\n"+
+		"UR  - https://madek.example.com/entries/e1\r\n"+
+		"ER  - \r\n", citation.RIS())
+
+	data, err := json.Marshal(citation.CSL())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "e1",
+		"type": "graphic",
+		"title": "Form & Function_1",
+		"author": [{"family": "Doe", "given": "Jane"}, {"literal": "Studio X"}],
+		"issued": {"date-parts": [[2016]]},
+		"publisher": "Department",
+		"keyword": "Design",
+		"URL": "https://madek.example.com/entries/e1"
+	}`, string(data))
+
+	coll := &Collection{ID: "c1", MetaData: &MetaData{Title: "Collection", Year: "ca. 1990"}}
+	citation = coll.Citation("")
+	assert.Equal(t, "@misc{madek:c1,\n  title = {Collection},\n  year = {ca. 1990}\n}\n", citation.BibTeX())
+	assert.Equal(t, "TY  - GEN\r\nID  - c1\r\nTI  - Collection\r\nPY  - 1990\r\nER  - \r\n", citation.RIS())
+	assert.Equal(t, &CSLItem{ID: "c1", Type: "collection", Title: "Collection", Issued: &CSLDate{Raw: "ca. 1990"}}, citation.CSL())
+}
@@ -1,11 +1,15 @@
 package madek
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,8 +29,50 @@ var ErrRequestFailed = errors.New("request failed")
 // ErrNotFound is returned when the requested resource ist not found.
 var ErrNotFound = errors.New("not found")
 
+// ErrQuotaExceeded is returned when an upload exceeds the available quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrValidationFailed is returned when the submitted data has been rejected.
+var ErrValidationFailed = errors.New("validation failed")
+
+// A RequestError is returned by requests that modify data. It wraps one of
+// the above errors and carries the status code and message returned by the
+// API.
+type RequestError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+// Error implements the error interface.
+func (e *RequestError) Error() string {
+	// check message
+	if e.Message == "" {
+		return fmt.Sprintf("%s (%d)", e.Err, e.Status)
+	}
+
+	return fmt.Sprintf("%s: %s (%d)", e.Err, e.Message, e.Status)
+}
+
+// Unwrap will return the wrapped error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
 // A Client is used to request data from the Madek API.
 type Client struct {
+	// Root is the path of the API root that is used to discover the API
+	// relations. It defaults to "/api/".
+	Root string
+
+	// PageSize is passed as a hint to the API when requesting paginated
+	// collections. The API may choose to ignore it.
+	PageSize int
+
+	// IncludePermissions enables compiling the permissions of collections
+	// and media entries.
+	IncludePermissions bool
+
 	client       http.Client
 	address      string
 	username     string
@@ -36,11 +82,14 @@ type Client struct {
 	keywordCache map[string]string
 	licenseCache map[string]string
 	mutex        sync.Mutex
+	relations    map[string]string
+	relMutex     sync.Mutex
 }
 
 // NewClient will create and return a new Client.
 func NewClient(address, username, password string) *Client {
 	return &Client{
+		Root:         "/api/",
 		address:      address,
 		username:     username,
 		password:     password,
@@ -55,7 +104,7 @@ func NewClient(address, username, password string) *Client {
 // from the API.
 func (c *Client) CompileCollection(id string) (*Collection, error) {
 	// fetch collection
-	collStr, err := c.Fetch(c.URL("/api/collections/%s", id))
+	collStr, err := c.fetchByID("collection", id)
 	if err != nil {
 		return nil, err
 	}
@@ -72,79 +121,44 @@ func (c *Client) CompileCollection(id string) (*Collection, error) {
 		CreatedAt: createdAt,
 	}
 
-	// fetch meta data
-	coll.MetaData, err = c.CompileMetaData(c.URL("/api/collections/%s/meta-data/", id))
+	// parse update times
+	coll.UpdatedAt, coll.MetaDataUpdatedAt, coll.EditSessionUpdatedAt, err = parseUpdateTimes(collStr)
 	if err != nil {
 		return nil, err
 	}
 
-	// prepare media entries
-	var mediaEntryIds []string
+	// get meta data url
+	metaDataURL, err := c.followOrExpand(collStr, "meta-data", "collection-meta-data", map[string]string{"id": id})
+	if err != nil {
+		return nil, err
+	}
 
-	// fetch all media entries
-	for page := 0; ; page++ {
-		// fetch media entry
-		mediaEntriesStr, err := c.Fetch(c.URL("/api/media-entries/?collection_id=%s&page=%d", id, page))
+	// fetch meta data
+	coll.MetaData, err = c.CompileMetaData(metaDataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch permissions
+	if c.IncludePermissions {
+		coll.Permissions, err = c.GetPermissions(CollectionKind, id)
 		if err != nil {
 			return nil, err
 		}
-
-		// append ids
-		for _, id := range gjson.Get(mediaEntriesStr, "media-entries.#.id").Array() {
-			mediaEntryIds = append(mediaEntryIds, id.Str)
-		}
-
-		// check if there is a next page
-		if !gjson.Get(mediaEntriesStr, "_json-roa.collection.next").Exists() {
-			break
-		}
-	}
-
-	// prepare wait group
-	var wg sync.WaitGroup
-	wg.Add(len(mediaEntryIds))
-
-	// prepare result
-	asyncErrors := make(chan error, len(mediaEntryIds))
-	mediaEntries := make(chan *MediaEntry, len(mediaEntryIds))
-
-	// compile media entries concurrently
-	for _, entryID := range mediaEntryIds {
-		go func(id string) {
-			defer wg.Done()
-
-			// compile media entry
-			mediaEntry, err := c.CompileMediaEntry(id)
-			if err != nil {
-				asyncErrors <- err
-				return
-			}
-
-			// send media entry
-			mediaEntries <- mediaEntry
-		}(entryID)
 	}
 
-	// await done
-	wg.Wait()
-	close(asyncErrors)
-	close(mediaEntries)
-
-	// check errors
-	if len(asyncErrors) > 0 {
-		return nil, <-asyncErrors
+	// fetch all media entries
+	mediaEntryIds, err := c.mediaEntryIDs(id)
+	if err != nil {
+		return nil, err
 	}
 
-	// collect media entries
-	for mediaEntry := range mediaEntries {
-		coll.MediaEntries = append(coll.MediaEntries, mediaEntry)
+	// compile media entries
+	coll.MediaEntries, err = c.compileMediaEntries(mediaEntryIds, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// sort media entries
-	sort.Slice(coll.MediaEntries, func(i, j int) bool {
-		return coll.MediaEntries[i].ID < coll.MediaEntries[j].ID
-	})
-
 	return coll, nil
 }
 
@@ -152,11 +166,15 @@ func (c *Client) CompileCollection(id string) (*Collection, error) {
 // from the API.
 func (c *Client) CompileMediaEntry(id string) (*MediaEntry, error) {
 	// fetch media entry
-	mediaEntryStr, err := c.Fetch(c.URL("/api/media-entries/%s", id))
+	mediaEntryStr, err := c.fetchByID("media-entry", id)
 	if err != nil {
 		return nil, err
 	}
 
+	return c.compileMediaEntry(id, mediaEntryStr)
+}
+
+func (c *Client) compileMediaEntry(id, mediaEntryStr string) (*MediaEntry, error) {
 	// parse time
 	createdAt, err := time.Parse(time.RFC3339, gjson.Get(mediaEntryStr, "created_at").Str)
 	if err != nil {
@@ -169,14 +187,40 @@ func (c *Client) CompileMediaEntry(id string) (*MediaEntry, error) {
 		CreatedAt: createdAt,
 	}
 
+	// parse update times
+	mediaEntry.UpdatedAt, mediaEntry.MetaDataUpdatedAt, mediaEntry.EditSessionUpdatedAt, err = parseUpdateTimes(mediaEntryStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// get meta data url
+	metaDataURL, err := c.followOrExpand(mediaEntryStr, "meta-data", "media-entry-meta-data", map[string]string{"id": id})
+	if err != nil {
+		return nil, err
+	}
+
 	// compile meta data
-	mediaEntry.MetaData, err = c.CompileMetaData(c.URL("/api/media-entries/%s/meta-data/", id))
+	mediaEntry.MetaData, err = c.CompileMetaData(metaDataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch permissions
+	if c.IncludePermissions {
+		mediaEntry.Permissions, err = c.GetPermissions(MediaEntryKind, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// get media file url
+	mediaFileURL, err := c.Follow(mediaEntryStr, "media-file", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// fetch media file
-	mediaFileStr, err := c.Fetch(c.URL(gjson.Get(mediaEntryStr, "_json-roa.relations.media-file.href").Str))
+	mediaFileStr, err := c.Fetch(mediaFileURL)
 	if err != nil {
 		return nil, err
 	}
@@ -186,7 +230,10 @@ func (c *Client) CompileMediaEntry(id string) (*MediaEntry, error) {
 	mediaEntry.FileName = gjson.Get(mediaFileStr, "filename").Str
 	mediaEntry.FileType = gjson.Get(mediaFileStr, "content_type").Str
 	mediaEntry.FileSize = gjson.Get(mediaFileStr, "size").Int()
-	mediaEntry.StreamURL = c.URL(gjson.Get(mediaFileStr, "_json-roa.relations.data-stream.href").Str)
+	mediaEntry.StreamURL, err = c.followOrExpand(mediaFileStr, "data-stream", "media-file-data-stream", map[string]string{"id": mediaEntry.FileID})
+	if err != nil {
+		return nil, err
+	}
 	mediaEntry.DownloadURL = c.URL("/files/%s", mediaEntry.FileID)
 
 	// collect previews
@@ -206,7 +253,7 @@ func (c *Client) CompileMediaEntry(id string) (*MediaEntry, error) {
 			defer wg.Done()
 
 			// fetch preview
-			previewStr, err := c.Fetch(c.URL("/api/previews/%s", pid))
+			previewStr, err := c.fetchByID("preview", pid)
 			if err != nil {
 				asyncErrors <- err
 				return
@@ -220,6 +267,7 @@ func (c *Client) CompileMediaEntry(id string) (*MediaEntry, error) {
 				Size:        gjson.Get(previewStr, "thumbnail").Str,
 				Width:       int(gjson.Get(previewStr, "width").Num),
 				Height:      int(gjson.Get(previewStr, "height").Num),
+				Duration:    gjson.Get(previewStr, "duration").Num,
 				URL:         c.URL("/media/%s", pid),
 			}
 		}(previewID.Str)
@@ -271,7 +319,7 @@ func (c *Client) CompileMetaData(url string) (*MetaData, error) {
 		}
 
 		// fetch meta datum
-		metaDatumStr, err := c.Fetch(c.URL("/api/meta-data/%s", metaID))
+		metaDatumStr, err := c.fetchByID("meta-datum", metaID)
 		if err != nil {
 			return nil, err
 		}
@@ -375,7 +423,7 @@ func (c *Client) GetAuthor(id string) (*Author, error) {
 	}
 
 	// fetch person
-	person, err := c.Fetch(c.URL("/api/people/%s", id))
+	person, err := c.fetchByID("person", id)
 	if err != nil {
 		return nil, err
 	}
@@ -405,7 +453,7 @@ func (c *Client) GetGroup(id string) (*Group, error) {
 	}
 
 	// fetch group
-	groupStr, err := c.Fetch(c.URL("/api/people/%s", id))
+	groupStr, err := c.fetchByID("person", id)
 	if err != nil {
 		return nil, err
 	}
@@ -435,7 +483,7 @@ func (c *Client) GetKeywordTerm(id string) (string, error) {
 	}
 
 	// fetch keyword
-	keyword, err := c.Fetch(c.URL("/api/keywords/%s", id))
+	keyword, err := c.fetchByID("keyword", id)
 	if err != nil {
 		return "", err
 	}
@@ -459,7 +507,7 @@ func (c *Client) GetLicenseLabel(id string) (string, error) {
 	}
 
 	// fetch license
-	license, err := c.Fetch(c.URL("/api/licenses/%s", id))
+	license, err := c.fetchByID("license", id)
 	if err != nil {
 		return "", err
 	}
@@ -477,16 +525,49 @@ func (c *Client) URL(format string, args ...interface{}) string {
 	return fmt.Sprintf("%s"+format, args...)
 }
 
+func (c *Client) fetchByID(relation, id string) (string, error) {
+	// get url
+	url, err := c.Expand(relation, map[string]string{
+		"id": id,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return c.Fetch(url)
+}
+
+func (c *Client) resolve(href string) string {
+	// check if absolute
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href
+	}
+
+	return c.address + href
+}
+
+func (c *Client) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	// prepare request
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// set authentication
+	req.SetBasicAuth(c.username, c.password)
+
+	return req, nil
+}
+
 // Fetch will request the specified URL from Madek.
 func (c *Client) Fetch(url string) (string, error) {
 	// prepare request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest("GET", url, nil)
 	if err != nil {
 		return "", err
 	}
 
 	// set headers
-	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set("Accept", "application/json-roa+json")
 
 	// perform request
@@ -505,18 +586,113 @@ func (c *Client) Fetch(url string) (string, error) {
 	}
 
 	// check status code
-	switch res.StatusCode {
+	if res.StatusCode != http.StatusOK {
+		return "", statusError(res.StatusCode)
+	}
+
+	return string(bytes), nil
+}
+
+// Send will send a request with the provided method and body to the
+// specified URL and return the response body. Failures are returned as a
+// RequestError.
+func (c *Client) Send(method, url, contentType string, body io.Reader) (string, error) {
+	// prepare request
+	req, err := c.newRequest(method, url, body)
+	if err != nil {
+		return "", err
+	}
+
+	// set headers
+	req.Header.Set("Accept", "application/json-roa+json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// perform request
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	// ensure body close
+	defer res.Body.Close()
+
+	// read body
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// check status code
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		// get message
+		var message string
+		for _, key := range []string{"message", "error", "msg"} {
+			if message = gjson.GetBytes(data, key).Str; message != "" {
+				break
+			}
+		}
+
+		return "", &RequestError{
+			Status:  res.StatusCode,
+			Message: message,
+			Err:     statusError(res.StatusCode),
+		}
+	}
+
+	return string(data), nil
+}
+
+// SendJSON will send the provided value as JSON using Send.
+func (c *Client) SendJSON(method, url string, value interface{}) (string, error) {
+	// encode value
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Send(method, url, "application/json", bytes.NewReader(data))
+}
+
+func statusError(code int) error {
+	switch code {
 	case http.StatusUnauthorized:
-		return "", ErrInvalidAuthentication
+		return ErrInvalidAuthentication
 	case http.StatusForbidden:
-		return "", ErrAccessForbidden
+		return ErrAccessForbidden
 	case http.StatusNotFound:
-		return "", ErrNotFound
-	case http.StatusOK:
-		return string(bytes), nil
+		return ErrNotFound
+	case http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage:
+		return ErrQuotaExceeded
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+		return ErrValidationFailed
 	default:
-		return "", ErrRequestFailed
+		return ErrRequestFailed
+	}
+}
+
+func parseUpdateTimes(doc string) (updated, metaData, editSession *time.Time, err error) {
+	// prepare list
+	times := make([]*time.Time, 3)
+
+	for i, key := range []string{"updated_at", "meta_data_updated_at", "edit_session_updated_at"} {
+		// skip missing values
+		str := gjson.Get(doc, key).Str
+		if str == "" {
+			continue
+		}
+
+		// parse time
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		times[i] = &t
 	}
+
+	return times[0], times[1], times[2], nil
 }
 
 func stringInList(list []string, str string) bool {
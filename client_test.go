@@ -24,6 +24,14 @@ func TestClient(t *testing.T) {
 		coll.MediaEntries[3],
 	}
 
+	// update times change with every edit
+	assert.False(t, coll.ModifiedAt().Before(coll.CreatedAt))
+	coll.UpdatedAt, coll.MetaDataUpdatedAt, coll.EditSessionUpdatedAt = nil, nil, nil
+	for _, entry := range coll.MediaEntries {
+		assert.False(t, entry.ModifiedAt().Before(entry.CreatedAt))
+		entry.UpdatedAt, entry.MetaDataUpdatedAt, entry.EditSessionUpdatedAt = nil, nil, nil
+	}
+
 	bytes, err := json.MarshalIndent(coll, "", "  ")
 	assert.NoError(t, err)
 
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// The supported authentication methods of profiles.
+const (
+	authAuto   = ""
+	authNone   = "none"
+	authEnv    = "env"
+	authNetrc  = "netrc"
+	authPrompt = "prompt"
+	authHelper = "helper"
+)
+
+type config struct {
+	// The name of the profile used if none is selected.
+	Default string `json:"default"`
+
+	// The named instance profiles.
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+type profile struct {
+	// The address of the Madek instance.
+	Address string `json:"address"`
+
+	// The username for authentication.
+	Username string `json:"username"`
+
+	// The authentication method: "none", "env", "netrc", "prompt" or
+	// "helper". If empty, the environment, .netrc and a prompt are tried.
+	Auth string `json:"auth"`
+
+	// The environment variable holding the password.
+	PasswordEnv string `json:"password_env"`
+
+	// The shell command that prints the password or "key=value" lines with a
+	// username and password.
+	Helper string `json:"helper"`
+
+	// The resolved password.
+	password string
+}
+
+func defaultConfigPath() string {
+	// check environment
+	if path := os.Getenv("MADEK_CONFIG"); path != "" {
+		return path
+	}
+
+	// get user config directory
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "madek", "config.json")
+}
+
+func loadConfig(path string) (*config, error) {
+	// prepare config
+	cfg := &config{
+		Profiles: map[string]*profile{},
+	}
+
+	// read file
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// decode config
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *config) profile(name string, fallback bool) (*profile, error) {
+	// use default if enabled
+	if name == "" && fallback {
+		name = c.Default
+	}
+
+	// check name
+	if name == "" {
+		return &profile{}, nil
+	}
+
+	// get profile
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %s", name)
+	}
+
+	// copy profile
+	cp := *p
+
+	return &cp, nil
+}
+
+func (p *profile) resolve(passwordEnv string) error {
+	// set default variable
+	if p.PasswordEnv == "" {
+		p.PasswordEnv = passwordEnv
+	}
+
+	// resolve credentials
+	switch p.Auth {
+	case authNone:
+		p.Username, p.password = "", ""
+		return nil
+	case authEnv:
+		p.password = os.Getenv(p.PasswordEnv)
+		return nil
+	case authNetrc:
+		return p.readNetrc()
+	case authPrompt:
+		return p.prompt()
+	case authHelper:
+		return p.runHelper()
+	case authAuto:
+		// try environment
+		if p.password = os.Getenv(p.PasswordEnv); p.password != "" {
+			return nil
+		}
+
+		// try .netrc
+		err := p.readNetrc()
+		if err != nil || p.password != "" {
+			return err
+		}
+
+		// prompt if a username is known and stdin is a terminal
+		if p.Username != "" && term.IsTerminal(int(os.Stdin.Fd())) {
+			return p.prompt()
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown authentication method: %s", p.Auth)
+	}
+}
+
+func (p *profile) readNetrc() error {
+	// get path
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	// read file
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// get host
+	u, err := url.Parse(p.Address)
+	if err != nil {
+		return err
+	}
+
+	// find machine
+	login, password := parseNetrc(string(data), u.Hostname(), p.Username)
+	if password != "" {
+		p.password = password
+		if p.Username == "" {
+			p.Username = login
+		}
+	}
+
+	return nil
+}
+
+func (p *profile) prompt() error {
+	// ask for username
+	reader := bufio.NewReader(os.Stdin)
+	if p.Username == "" {
+		fmt.Fprintf(os.Stderr, "Username for %s: ", p.Address)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		p.Username = strings.TrimSpace(line)
+	}
+
+	// ask for password
+	fmt.Fprintf(os.Stderr, "Password for %s@%s: ", p.Username, p.Address)
+
+	// read password without echo from terminals
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return err
+		}
+		p.password = string(password)
+		return nil
+	}
+
+	// otherwise read line
+	line, err := reader.ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return err
+	}
+	p.password = strings.TrimRight(line, "\r\n")
+
+	return nil
+}
+
+func (p *profile) runHelper() error {
+	// check command
+	if p.Helper == "" {
+		return fmt.Errorf("missing credential helper for %s", p.Address)
+	}
+
+	// run command
+	cmd := exec.Command("sh", "-c", p.Helper)
+	cmd.Env = append(os.Environ(), "MADEK_ADDRESS="+p.Address, "MADEK_USERNAME="+p.Username)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("credential helper: %w", err)
+	}
+
+	// parse "key=value" lines
+	lines := strings.Split(strings.TrimRight(string(out), "\r\n"), "\n")
+	for _, line := range lines {
+		key, value, ok := splitPair(strings.TrimRight(line, "\r"))
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			p.Username = value
+		case "password":
+			p.password = value
+		}
+	}
+
+	// otherwise use first line as password
+	if p.password == "" && !strings.Contains(lines[0], "=") {
+		p.password = strings.TrimRight(lines[0], "\r")
+	}
+
+	return nil
+}
+
+func parseNetrc(data, host, username string) (string, string) {
+	// prepare state
+	var login, password string
+	var match, found bool
+
+	// collect tokens, skipping macro definitions up to the next blank line
+	var tokens []string
+	var macro bool
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if macro {
+			macro = len(fields) > 0
+			continue
+		}
+		for i, field := range fields {
+			if field == "macdef" {
+				fields, macro = fields[:i], true
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+
+	// scan tokens
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine", "default":
+			// finish previous entry
+			if match && password != "" && (username == "" || login == username) {
+				return login, password
+			}
+
+			// start entry
+			login, password = "", ""
+			if tokens[i] == "machine" && i+1 < len(tokens) {
+				i++
+				match = tokens[i] == host
+			} else {
+				match = !found
+			}
+			found = found || match
+		case "login":
+			if i+1 < len(tokens) {
+				i++
+				login = tokens[i]
+			}
+		case "password":
+			if i+1 < len(tokens) {
+				i++
+				password = tokens[i]
+			}
+		}
+	}
+
+	// check last entry
+	if match && password != "" && (username == "" || login == username) {
+		return login, password
+	}
+
+	return "", ""
+}
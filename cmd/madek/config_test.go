@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigProfile(t *testing.T) {
+	cfg := &config{
+		Default: "main",
+		Profiles: map[string]*profile{
+			"main":  {Address: "https://main.example.com"},
+			"other": {Address: "https://other.example.com"},
+		},
+	}
+
+	for _, item := range []struct {
+		name     string
+		fallback bool
+		address  string
+		err      string
+	}{
+		{name: "", fallback: true, address: "https://main.example.com"},
+		{name: "", fallback: false, address: ""},
+		{name: "other", fallback: true, address: "https://other.example.com"},
+		{name: "other", fallback: false, address: "https://other.example.com"},
+		{name: "missing", fallback: true, err: "unknown profile: missing"},
+	} {
+		p, err := cfg.profile(item.name, item.fallback)
+		if item.err != "" {
+			assert.EqualError(t, err, item.err, item.name)
+			continue
+		}
+		assert.NoError(t, err, item.name)
+		assert.Equal(t, item.address, p.Address, item.name)
+	}
+
+	p, err := cfg.profile("main", false)
+	assert.NoError(t, err)
+	p.Address = "changed"
+	assert.Equal(t, "https://main.example.com", cfg.Profiles["main"].Address)
+}
+
+func TestParseNetrc(t *testing.T) {
+	data := `machine other.example.com login foo password bar
+
+machine madek.example.com
+	login alice
+	password secret1
+machine madek.example.com login bob password secret2
+
+macdef init
+machine madek.example.com login eve password evil
+
+default login guest password guest
+`
+
+	for _, item := range []struct {
+		data     string
+		host     string
+		username string
+		login    string
+		password string
+	}{
+		{data: data, host: "madek.example.com", login: "alice", password: "secret1"},
+		{data: data, host: "madek.example.com", username: "bob", login: "bob", password: "secret2"},
+		{data: data, host: "madek.example.com", username: "eve"},
+		{data: data, host: "unknown.example.com", login: "guest", password: "guest"},
+		{data: "machine madek.example.com login alice", host: "madek.example.com"},
+		{data: "", host: "madek.example.com"},
+	} {
+		login, password := parseNetrc(item.data, item.host, item.username)
+		assert.Equal(t, item.login, login, item.host+" "+item.username)
+		assert.Equal(t, item.password, password, item.host+" "+item.username)
+	}
+}
+
+func TestProfileHelper(t *testing.T) {
+	for _, item := range []struct {
+		helper   string
+		username string
+		password string
+		err      bool
+	}{
+		{helper: "echo secret", username: "alice", password: "secret"},
+		{helper: `printf 'username=bob\npassword=secret\n'`, username: "bob", password: "secret"},
+		{helper: `printf 'password=%s\n' "$MADEK_USERNAME"`, username: "alice", password: "alice"},
+		{helper: "exit 1", username: "alice", err: true},
+		{helper: "", username: "alice", err: true},
+	} {
+		p := &profile{
+			Address:  "https://madek.example.com",
+			Username: "alice",
+			Auth:     authHelper,
+			Helper:   item.helper,
+		}
+		err := p.resolve("MADEK_PASSWORD")
+		if item.err {
+			assert.Error(t, err, item.helper)
+			continue
+		}
+		assert.NoError(t, err, item.helper)
+		assert.Equal(t, item.username, p.Username, item.helper)
+		assert.Equal(t, item.password, p.password, item.helper)
+	}
+}
+
+func TestProfileResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "madek")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	netrc := filepath.Join(dir, "netrc")
+	err = ioutil.WriteFile(netrc, []byte("machine madek.example.com login alice password netrc"), 0600)
+	assert.NoError(t, err)
+
+	defer os.Setenv("NETRC", os.Getenv("NETRC"))
+	os.Setenv("NETRC", netrc)
+	defer os.Unsetenv("MADEK_TEST_PASSWORD")
+
+	for _, item := range []struct {
+		auth     string
+		env      string
+		username string
+		password string
+	}{
+		{auth: authAuto, username: "alice", password: "netrc"},
+		{auth: authAuto, env: "env", username: "", password: "env"},
+		{auth: authEnv, username: "", password: ""},
+		{auth: authEnv, env: "env", username: "", password: "env"},
+		{auth: authNetrc, env: "env", username: "alice", password: "netrc"},
+		{auth: authNone, env: "env", username: "", password: ""},
+	} {
+		os.Setenv("MADEK_TEST_PASSWORD", item.env)
+		p := &profile{
+			Address: "https://madek.example.com",
+			Auth:    item.auth,
+		}
+		err := p.resolve("MADEK_TEST_PASSWORD")
+		assert.NoError(t, err, item.auth)
+		assert.Equal(t, item.username, p.Username, item.auth)
+		assert.Equal(t, item.password, p.password, item.auth)
+	}
+
+	p := &profile{Auth: "unknown"}
+	assert.EqualError(t, p.resolve("MADEK_TEST_PASSWORD"), "unknown authentication method: unknown")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/256dpi/madek"
+)
+
+func diff(_ *madek.Client, args []string) error {
+	// load collections
+	var colls [2]*madek.Collection
+	for i, file := range args {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(data, &colls[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		} else if colls[i] == nil {
+			return fmt.Errorf("%w: %s does not contain a collection", errUsage, file)
+		}
+	}
+
+	// compute diff
+	d := madek.DiffCollections(colls[0], colls[1])
+
+	// print text
+	if !*asJSON {
+		fmt.Print(d.String())
+		return nil
+	}
+
+	return writeIndentedJSON(os.Stdout, d)
+}
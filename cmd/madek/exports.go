@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/256dpi/madek"
+)
+
+func exportIIIF(client *madek.Client, args []string) error {
+	// check base url
+	if *baseURL == "" {
+		return fmt.Errorf("%w: missing base url", errUsage)
+	}
+
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	// prepare exporter
+	exporter := &madek.IIIFExporter{
+		BaseURL:  *baseURL,
+		Address:  client.URL(""),
+		Language: *language,
+	}
+
+	return writeFile(args[1:], func(w io.Writer) error {
+		return writeIndentedJSON(w, exporter.Export(coll))
+	})
+}
+
+func exportJSONLD(client *madek.Client, args []string) error {
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	return writeFile(args[1:], func(w io.Writer) error {
+		return writeIndentedJSON(w, coll.SchemaOrg(client.URL("")))
+	})
+}
+
+func exportFeed(client *madek.Client, args []string) error {
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	// prepare generator
+	generator := &madek.FeedGenerator{
+		FeedURL: *baseURL,
+		Address: client.URL(""),
+		Limit:   *limit,
+	}
+
+	return writeFile(args[1:], func(w io.Writer) error {
+		return generator.Write(w, coll, *feedFormat)
+	})
+}
+
+func generateSite(client *madek.Client, args []string) error {
+	// get templates
+	tmpl, err := madek.SiteTemplates()
+	if err != nil {
+		return err
+	}
+
+	// parse overrides
+	if *templates != "" {
+		tmpl, err = tmpl.ParseGlob(filepath.Join(*templates, "*.html"))
+		if err != nil {
+			return err
+		}
+	}
+
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	// prepare generator
+	generator := &madek.SiteGenerator{
+		Client:       client,
+		Directory:    args[1],
+		Address:      client.URL(""),
+		Templates:    tmpl,
+		CopyPreviews: *copyPreviews,
+		Log: func(msg string) {
+			fmt.Println(msg)
+		},
+	}
+
+	// generate site
+	err = generator.Generate(coll)
+	if err != nil {
+		return err
+	}
+
+	// print summary
+	fmt.Printf("Generated %d pages in %s\n", len(coll.MediaEntries)+1, args[1])
+
+	return nil
+}
+
+func cite(client *madek.Client, args []string) error {
+	// resolve reference
+	value, err := client.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	// get citation
+	var citation *madek.Citation
+	switch value := value.(type) {
+	case *madek.MediaEntry:
+		citation = value.Citation(client.URL(""))
+	case *madek.Collection:
+		citation = value.Citation(client.URL(""))
+	default:
+		return fmt.Errorf("%w: not a media entry or collection: %s", errUsage, args[0])
+	}
+
+	// print citation
+	switch *style {
+	case "bibtex":
+		fmt.Print(citation.BibTeX())
+	case "ris":
+		fmt.Print(citation.RIS())
+	case "csl":
+		return writeIndentedJSON(os.Stdout, []*madek.CSLItem{citation.CSL()})
+	case "":
+		fmt.Println(citation.BibTeX())
+		fmt.Println(citation.RIS())
+		return writeIndentedJSON(os.Stdout, []*madek.CSLItem{citation.CSL()})
+	default:
+		return fmt.Errorf("%w: unknown style %q", errUsage, *style)
+	}
+
+	return nil
+}
+
+func writeFile(args []string, fn func(w io.Writer) error) error {
+	// print if no file is given
+	if len(args) == 0 {
+		return fn(os.Stdout)
+	}
+
+	// create file
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+
+	// ensure close
+	defer f.Close()
+
+	// write file
+	err = fn(f)
+	if err != nil {
+		return err
+	}
+
+	// close file
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	// print summary
+	fmt.Printf("Exported to %s\n", args[0])
+
+	return nil
+}
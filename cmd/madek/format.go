@@ -0,0 +1,385 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/256dpi/madek"
+	"gopkg.in/yaml.v3"
+)
+
+// The columns of tabular media entry output.
+var entryColumns = []string{
+	"id", "title", "subtitle", "description", "authors", "keywords", "genres",
+	"year", "affiliation", "copyright_holder", "copyright_usage", "licenses",
+	"file_name", "file_type", "file_size", "created_at", "updated_at",
+}
+
+// The supported output formats.
+var formats = []string{"json", "ndjson", "yaml", "csv", "table"}
+
+// The maximum width of table cells.
+const cellWidth = 40
+
+type table struct {
+	columns []string
+	rows    []map[string]string
+}
+
+func checkOutput(cmd *command) error {
+	// check format
+	if !stringInList(formats, *format) {
+		return fmt.Errorf("%w: unknown format: %s", errUsage, *format)
+	}
+
+	// check columns
+	if *columns == "" {
+		return nil
+	} else if *format != "csv" && *format != "table" {
+		return fmt.Errorf("%w: columns require the csv or table format", errUsage)
+	}
+
+	// check known columns, other columns are checked when tabulated
+	if cmd.columns != nil {
+		_, err := selectColumns(cmd.columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func output(w io.Writer, value interface{}) error {
+	// write value
+	switch *format {
+	case "json":
+		return writeIndentedJSON(w, value)
+	case "ndjson":
+		return writeNDJSON(w, value)
+	case "yaml":
+		return writeYAML(w, value)
+	case "csv":
+		return writeCSV(w, value)
+	case "table":
+		return writeTable(w, value)
+	default:
+		return fmt.Errorf("%w: unknown format: %s", errUsage, *format)
+	}
+}
+
+func writeIndentedJSON(w io.Writer, value interface{}) error {
+	// encode
+	bytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write
+	_, err = fmt.Fprintln(w, string(bytes))
+
+	return err
+}
+
+func writeNDJSON(w io.Writer, value interface{}) error {
+	// write items
+	enc := json.NewEncoder(w)
+	for _, item := range items(value) {
+		err := enc.Encode(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeYAML(w io.Writer, value interface{}) error {
+	// convert to generic value to keep JSON field names
+	generic, err := toGeneric(value)
+	if err != nil {
+		return err
+	}
+
+	// encode
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(generic)
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func writeCSV(w io.Writer, value interface{}) error {
+	// get table
+	tbl, err := tabulate(value)
+	if err != nil {
+		return err
+	}
+
+	// write header
+	cw := csv.NewWriter(w)
+	err = cw.Write(tbl.columns)
+	if err != nil {
+		return err
+	}
+
+	// write rows
+	for _, row := range tbl.rows {
+		record := make([]string, 0, len(tbl.columns))
+		for _, column := range tbl.columns {
+			record = append(record, row[column])
+		}
+		err = cw.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	// flush
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, value interface{}) error {
+	// get table
+	tbl, err := tabulate(value)
+	if err != nil {
+		return err
+	}
+
+	// write header
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, 0, len(tbl.columns))
+	for _, column := range tbl.columns {
+		header = append(header, strings.ToUpper(column))
+	}
+	_, _ = fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	// write rows
+	for _, row := range tbl.rows {
+		cells := make([]string, 0, len(tbl.columns))
+		for _, column := range tbl.columns {
+			cells = append(cells, truncate(row[column]))
+		}
+		_, _ = fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func items(value interface{}) []interface{} {
+	// unwrap lists
+	switch value := value.(type) {
+	case *madek.Collection:
+		list := make([]interface{}, 0, len(value.MediaEntries))
+		for _, entry := range value.MediaEntries {
+			list = append(list, entry)
+		}
+		return list
+	case []*madek.MediaEntry:
+		list := make([]interface{}, 0, len(value))
+		for _, entry := range value {
+			list = append(list, entry)
+		}
+		return list
+	default:
+		return []interface{}{value}
+	}
+}
+
+func tabulate(value interface{}) (*table, error) {
+	// prepare table
+	tbl := &table{}
+
+	// use fixed columns for lists of media entries, even if empty
+	switch value.(type) {
+	case *madek.Collection, []*madek.MediaEntry:
+		tbl.columns = entryColumns
+	}
+
+	// flatten items
+	for _, item := range items(value) {
+		// flatten media entries with fixed columns
+		if entry, ok := item.(*madek.MediaEntry); ok {
+			tbl.columns = entryColumns
+			tbl.rows = append(tbl.rows, entryRow(entry))
+			continue
+		}
+
+		// flatten other values generically
+		generic, err := toGeneric(item)
+		if err != nil {
+			return nil, err
+		}
+		row := map[string]string{}
+		flatten(row, "", generic)
+		tbl.rows = append(tbl.rows, row)
+	}
+
+	// collect generic columns
+	if tbl.columns == nil {
+		seen := map[string]bool{}
+		for _, row := range tbl.rows {
+			for column := range row {
+				if !seen[column] {
+					seen[column] = true
+					tbl.columns = append(tbl.columns, column)
+				}
+			}
+		}
+		sort.Strings(tbl.columns)
+	}
+
+	// select columns
+	var err error
+	tbl.columns, err = selectColumns(tbl.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return tbl, nil
+}
+
+func selectColumns(available []string) ([]string, error) {
+	// check selection
+	if *columns == "" {
+		return available, nil
+	}
+
+	// select columns
+	var list []string
+	for _, column := range strings.Split(*columns, ",") {
+		column = strings.TrimSpace(column)
+		if !stringInList(available, column) {
+			return nil, fmt.Errorf("%w: unknown column %q, available: %s", errUsage, column, strings.Join(available, ","))
+		}
+		list = append(list, column)
+	}
+
+	return list, nil
+}
+
+func entryRow(entry *madek.MediaEntry) map[string]string {
+	// prepare row
+	row := map[string]string{
+		"id":         entry.ID,
+		"file_name":  entry.FileName,
+		"file_type":  entry.FileType,
+		"file_size":  strconv.FormatInt(entry.FileSize, 10),
+		"created_at": formatTime(entry.CreatedAt),
+		"updated_at": formatTime(entry.ModifiedAt()),
+	}
+
+	// add meta data
+	if md := entry.MetaData; md != nil {
+		var authors, affiliation []string
+		for _, author := range md.Authors {
+			authors = append(authors, author.Name())
+		}
+		for _, group := range md.Affiliation {
+			affiliation = append(affiliation, group.Name)
+		}
+		row["title"] = md.Title
+		row["subtitle"] = md.Subtitle
+		row["description"] = md.Description
+		row["authors"] = strings.Join(authors, "; ")
+		row["keywords"] = strings.Join(md.Keywords, "; ")
+		row["genres"] = strings.Join(md.Genres, "; ")
+		row["year"] = md.Year
+		row["affiliation"] = strings.Join(affiliation, "; ")
+		row["copyright_holder"] = md.Copyright.Holder
+		row["copyright_usage"] = md.Copyright.Usage
+		row["licenses"] = strings.Join(md.Copyright.Licenses, "; ")
+	}
+
+	return row
+}
+
+func flatten(row map[string]string, prefix string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(row, key, item)
+		}
+	case []interface{}:
+		var list []string
+		for _, item := range value {
+			switch item := item.(type) {
+			case map[string]interface{}, []interface{}:
+				bytes, _ := json.Marshal(item)
+				list = append(list, string(bytes))
+			default:
+				list = append(list, fmt.Sprint(item))
+			}
+		}
+		row[prefix] = strings.Join(list, "; ")
+	case float64:
+		row[prefix] = strconv.FormatFloat(value, 'f', -1, 64)
+	case nil:
+		row[prefix] = ""
+	default:
+		row[prefix] = fmt.Sprint(value)
+	}
+}
+
+func toGeneric(value interface{}) (interface{}, error) {
+	// encode
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode
+	var generic interface{}
+	err = json.Unmarshal(bytes, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+func formatTime(t time.Time) string {
+	// check zero
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func truncate(str string) string {
+	// collapse whitespace
+	str = strings.Join(strings.Fields(str), " ")
+
+	// truncate
+	if runes := []rune(str); len(runes) > cellWidth {
+		return string(runes[:cellWidth-1]) + "…"
+	}
+
+	return str
+}
+
+func stringInList(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/256dpi/madek"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOutput(t *testing.T) {
+	defer func(f, c string) {
+		*format, *columns = f, c
+	}(*format, *columns)
+
+	for _, item := range []struct {
+		cmd     string
+		format  string
+		columns string
+		err     string
+	}{
+		{cmd: "collection", format: "json"},
+		{cmd: "collection", format: "xml", err: "usage error: unknown format: xml"},
+		{cmd: "collection", format: "json", columns: "id", err: "usage error: columns require the csv or table format"},
+		{cmd: "collection", format: "csv", columns: "id, title"},
+		{cmd: "search", format: "table", columns: "id,name", err: `usage error: unknown column "name", available: ` + strings.Join(entryColumns, ",")},
+		{cmd: "person", format: "csv", columns: "name"},
+	} {
+		*format, *columns = item.format, item.columns
+		err := checkOutput(lookup(item.cmd))
+		if item.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.True(t, errors.Is(err, errUsage))
+			assert.Equal(t, item.err, err.Error())
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	defer func(c string) {
+		*columns = c
+	}(*columns)
+
+	var buf bytes.Buffer
+	err := writeCSV(&buf, &madek.Collection{ID: "c1"})
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Join(entryColumns, ",")+"\n", buf.String())
+
+	*columns = "id,title"
+	buf.Reset()
+	err = writeCSV(&buf, []*madek.MediaEntry{
+		{ID: "e1", MetaData: &madek.MetaData{Title: "Image"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,title\ne1,Image\n", buf.String())
+}
@@ -2,39 +2,488 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/256dpi/madek"
 )
 
 var address = flag.String("address", "https://medienarchiv.zhdk.ch", "The address of the Madek instance.")
 var username = flag.String("username", "", "The username for authentication.")
-var password = flag.String("password", "", "The password for authentication.")
+var configFile = flag.String("config", defaultConfigPath(), "The configuration file with instance profiles.")
+var profileName = flag.String("profile", os.Getenv("MADEK_PROFILE"), "The profile of the instance to use.")
+var previews = flag.String("previews", "", "The previews to mirror e.g. \"image:1024,video/webm:x1080\".")
+var skipFiles = flag.Bool("skip-files", false, "Do not mirror the original files.")
+var asJSON = flag.Bool("json", false, "Print diffs as JSON.")
+var permissions = flag.Bool("permissions", false, "Include permissions in compiled output.")
+var targetAddress = flag.String("target-address", "", "The address of the Madek instance to migrate to.")
+var targetUsername = flag.String("target-username", "", "The username for authentication with the target instance.")
+var targetProfile = flag.String("target-profile", "", "The profile of the instance to migrate to.")
+var targetCollection = flag.String("target-collection", "", "The id of an existing collection to migrate into.")
+var report = flag.String("report", "", "The file to read a previous and write the new migration report.")
+var collection = flag.String("collection", "", "The collection to search in.")
+var limit = flag.Int("limit", 0, "The maximum number of search results.")
+var format = flag.String("format", "json", "The output format: json, ndjson, yaml, csv or table.")
+var columns = flag.String("columns", "", "The comma separated columns of csv and table output.")
+var baseURL = flag.String("base-url", "", "The base URL exported documents are published at.")
+var language = flag.String("language", "", "The language of exported meta data values.")
+var listen = flag.String("listen", ":8080", "The address to listen on.")
+var interval = flag.Duration("interval", 5*time.Minute, "The interval at which served collections are refreshed.")
+var repositoryName = flag.String("name", "Madek", "The name of the served repository.")
+var copyPreviews = flag.Bool("copy-previews", false, "Copy previews into the generated site.")
+var templates = flag.String("templates", "", "The directory with templates that override the default site templates.")
+var feedFormat = flag.String("feed", "atom", "The feed format: atom, rss or json.")
+var style = flag.String("style", "", "The citation style: bibtex, ris or csl. Prints all if empty.")
+
+// The exit codes of the command line tool.
+const (
+	exitError    = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitNotFound = 4
+	exitNetwork  = 5
+)
+
+// errUsage is returned by commands if their arguments are invalid.
+var errUsage = errors.New("usage error")
+
+// globalFlags are the flags that apply to all commands.
+var globalFlags = []string{"profile", "config", "address", "username"}
+
+type command struct {
+	name    string
+	args    string
+	min     int
+	max     int
+	kind    madek.Kind
+	local   bool
+	flags   []string
+	columns []string
+	help    string
+	run     func(client *madek.Client, args []string) error
+}
+
+var commands = []*command{
+	{
+		name:    "collection",
+		args:    "<collection>",
+		min:     1,
+		max:     1,
+		kind:    madek.CollectionKind,
+		flags:   []string{"permissions", "format", "columns"},
+		columns: entryColumns,
+		help:    "Compile a collection with its media entries and print it.",
+		run:     compileCollection,
+	},
+	{
+		name:    "entry",
+		args:    "<entry>",
+		min:     1,
+		max:     1,
+		kind:    madek.MediaEntryKind,
+		flags:   []string{"permissions", "format", "columns"},
+		columns: entryColumns,
+		help:    "Compile a media entry and print it.",
+		run:     compileEntry,
+	},
+	{
+		name:  "person",
+		args:  "<person>",
+		min:   1,
+		max:   1,
+		kind:  madek.PersonKind,
+		flags: []string{"format", "columns"},
+		help:  "Fetch a person and print it.",
+		run:   getPerson,
+	},
+	{
+		name:  "keyword",
+		args:  "<keyword>",
+		min:   1,
+		max:   1,
+		kind:  madek.KeywordKind,
+		flags: []string{"format", "columns"},
+		help:  "Fetch a keyword and print it.",
+		run:   getKeyword,
+	},
+	{
+		name:  "license",
+		args:  "<license>",
+		min:   1,
+		max:   1,
+		flags: []string{"format", "columns"},
+		help:  "Fetch a license and print it.",
+		run:   getLicense,
+	},
+	{
+		name:    "search",
+		args:    "<text> [meta-key=value...]",
+		min:     1,
+		max:     -1,
+		flags:   []string{"collection", "limit", "permissions", "format", "columns"},
+		columns: entryColumns,
+		help:    "Search media entries by text and meta data and print them.",
+		run:     search,
+	},
+	{
+		name: "download",
+		args: "<entry> [file]",
+		min:  1,
+		max:  2,
+		kind: madek.MediaEntryKind,
+		help: "Download the original file of a media entry. Partial downloads are resumed.",
+		run:  download,
+	},
+	{
+		name:    "export",
+		args:    "<collection> [file]",
+		min:     1,
+		max:     2,
+		kind:    madek.CollectionKind,
+		flags:   []string{"permissions", "format", "columns"},
+		columns: entryColumns,
+		help:    "Compile a collection and write it to a file or standard output.",
+		run:     export,
+	},
+	{
+		name:  "iiif",
+		args:  "<collection> [file]",
+		min:   1,
+		max:   2,
+		kind:  madek.CollectionKind,
+		flags: []string{"base-url", "language", "permissions"},
+		help:  "Export a collection as a IIIF Presentation 3.0 manifest.",
+		run:   exportIIIF,
+	},
+	{
+		name:  "jsonld",
+		args:  "<collection> [file]",
+		min:   1,
+		max:   2,
+		kind:  madek.CollectionKind,
+		flags: []string{"permissions"},
+		help:  "Export a collection as schema.org JSON-LD.",
+		run:   exportJSONLD,
+	},
+	{
+		name:  "feed",
+		args:  "<collection> [file]",
+		min:   1,
+		max:   2,
+		kind:  madek.CollectionKind,
+		flags: []string{"feed", "base-url", "limit", "permissions"},
+		help:  "Export a collection as an Atom, RSS or JSON feed.",
+		run:   exportFeed,
+	},
+	{
+		name:  "site",
+		args:  "<collection> <directory>",
+		min:   2,
+		max:   2,
+		kind:  madek.CollectionKind,
+		flags: []string{"copy-previews", "templates", "permissions"},
+		help:  "Generate a static HTML gallery of a collection.",
+		run:   generateSite,
+	},
+	{
+		name:  "show",
+		args:  "<reference>",
+		min:   1,
+		max:   1,
+		flags: []string{"permissions", "format", "columns"},
+		help:  "Compile the collection, media entry, person or keyword referenced by an id or URL and print it.",
+		run:   show,
+	},
+	{
+		name:  "cite",
+		args:  "<entry|collection>",
+		min:   1,
+		max:   1,
+		flags: []string{"style"},
+		help:  "Print the citation of a media entry or collection as BibTeX, RIS and CSL-JSON.",
+		run:   cite,
+	},
+	{
+		name:  "oai",
+		args:  "<collection...>",
+		min:   1,
+		max:   -1,
+		kind:  madek.CollectionKind,
+		flags: []string{"listen", "base-url", "name", "permissions"},
+		help:  "Serve collections over OAI-PMH in the oai_dc format.",
+		run:   serveOAI,
+	},
+	{
+		name:  "serve",
+		args:  "[collection...]",
+		min:   0,
+		max:   -1,
+		kind:  madek.CollectionKind,
+		flags: []string{"listen", "interval", "base-url", "name"},
+		help:  "Serve cached collections, media entries, public previews and files, feeds, OAI-PMH and oEmbed over HTTP. Collections are compiled on demand if none are given.",
+		run:   serve,
+	},
+	{
+		name:  "mirror",
+		args:  "<collection> [directory]",
+		min:   1,
+		max:   2,
+		kind:  madek.CollectionKind,
+		flags: []string{"previews", "skip-files", "permissions"},
+		help:  "Mirror a collection with its files and previews to a directory.",
+		run:   mirror,
+	},
+	{
+		name:  "diff",
+		args:  "<old.json> <new.json>",
+		min:   2,
+		max:   2,
+		local: true,
+		flags: []string{"json"},
+		help:  "Compare two compiled collections.",
+		run:   diff,
+	},
+	{
+		name:  "migrate",
+		args:  "<collection>",
+		min:   1,
+		max:   1,
+		kind:  madek.CollectionKind,
+		flags: []string{"target-profile", "target-address", "target-username", "target-collection", "report"},
+		help:  "Migrate a collection with its media entries to another Madek instance.",
+		run:   migrate,
+	},
+}
 
 func main() {
-	// parse flags
+	// parse global flags
+	flag.Usage = usage
 	flag.Parse()
 
-	// get id
-	id := flag.Arg(0)
+	// get command
+	name := flag.Arg(0)
+	if name == "" {
+		usage()
+		os.Exit(exitUsage)
+	}
 
-	// prepare client
-	client := madek.NewClient(*address, *username, *password)
+	// handle help
+	if name == "help" {
+		if cmd := lookup(flag.Arg(1)); cmd != nil {
+			help(cmd)
+		} else {
+			usage()
+		}
+		return
+	}
+
+	// find command
+	cmd := lookup(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		usage()
+		os.Exit(exitUsage)
+	}
 
-	// compile collection
-	coll, err := client.CompileCollection(id)
+	// parse command flags
+	flag.Usage = func() {
+		help(cmd)
+	}
+	_ = flag.CommandLine.Parse(flag.Args()[1:])
+
+	// check flags
+	err := checkFlags(flag.CommandLine, cmd)
 	if err != nil {
-		fmt.Printf("Error encountered: %s\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error encountered: %s\n\n", err)
+		help(cmd)
+		os.Exit(exitUsage)
+	}
+
+	// check output
+	if stringInList(cmd.flags, "format") {
+		err = checkOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encountered: %s\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	// validate arguments
+	args := flag.Args()
+	if len(args) < cmd.min || (cmd.max >= 0 && len(args) > cmd.max) {
+		help(cmd)
+		os.Exit(exitUsage)
+	}
+
+	// prepare client for commands that access the API
+	var client *madek.Client
+	if !cmd.local {
+		client, err = newClient(*profileName, true, "address", "username", "MADEK_PASSWORD")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encountered: %s\n", err)
+			os.Exit(exitUsage)
+		}
+		client.IncludePermissions = *permissions
+	}
+
+	// resolve references
+	if cmd.kind != "" {
+		for i := range args {
+			if i > 0 && cmd.max >= 0 {
+				break
+			}
+			id, err := resolveID(client, args[i], cmd.kind)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error encountered: %s\n", err)
+				os.Exit(exitUsage)
+			}
+			args[i] = id
+		}
+	}
+
+	// run command
+	err = cmd.run(client, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encountered: %s\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func newClient(name string, fallback bool, addressFlag, usernameFlag, passwordEnv string) (*madek.Client, error) {
+	// load config
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// get profile
+	p, err := cfg.profile(name, fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	// apply flags if set or missing in profile
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case addressFlag:
+			p.Address = f.Value.String()
+		case usernameFlag:
+			p.Username = f.Value.String()
+		}
+	})
+	if p.Address == "" {
+		p.Address = flag.Lookup(addressFlag).Value.String()
 	}
 
+	// resolve credentials
+	err = p.resolve(passwordEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	return madek.NewClient(p.Address, p.Username, p.password), nil
+}
+
+func checkFlags(set *flag.FlagSet, cmd *command) error {
+	// check that all set flags apply to the command
+	var err error
+	set.Visit(func(f *flag.Flag) {
+		if err == nil && !stringInList(globalFlags, f.Name) && !stringInList(cmd.flags, f.Name) {
+			err = fmt.Errorf("%w: flag -%s does not apply to %s", errUsage, f.Name, cmd.name)
+		}
+	})
+
+	return err
+}
+
+func lookup(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func usage() {
+	// print usage
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: madek [flags] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-11s %s\n", cmd.name, cmd.help)
+	}
+	fmt.Fprintf(out, "\nRun \"madek help <command>\" for more information on a command.\n")
+	fmt.Fprintf(out, "\nCollections, media entries, people and keywords may be given as ids, web URLs,\n")
+	fmt.Fprintf(out, "API URLs or custom URLs like \"madek:collection:<id>\".\n")
+	fmt.Fprintf(out, "\nPasswords are read from $MADEK_PASSWORD, ~/.netrc, a prompt or the credential\n")
+	fmt.Fprintf(out, "helper of the selected profile.\n\nFlags:\n")
+	for _, name := range globalFlags {
+		printFlag(name)
+	}
+}
+
+func help(cmd *command) {
+	// print usage
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: madek [flags] %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.help)
+	for _, name := range append(globalFlags, cmd.flags...) {
+		printFlag(name)
+	}
+}
+
+func printFlag(name string) {
+	// get flag
+	f := flag.Lookup(name)
+
+	// print flag
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "  -%s\n    \t%s", f.Name, f.Usage)
+	if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+		fmt.Fprintf(out, " (default %q)", f.DefValue)
+	}
+	fmt.Fprintln(out)
+}
+
+func exitCode(err error) int {
+	// check errors
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, madek.ErrInvalidReference), errors.Is(err, madek.ErrForeignReference):
+		return exitUsage
+	case errors.Is(err, madek.ErrInvalidAuthentication), errors.Is(err, madek.ErrAccessForbidden):
+		return exitAuth
+	case errors.Is(err, madek.ErrNotFound):
+		return exitNotFound
+	case errors.As(err, &netErr):
+		return exitNetwork
+	default:
+		return exitError
+	}
+}
+
+func writeJSON(file string, value interface{}) error {
 	// encode
-	bytes, err := json.MarshalIndent(coll, "", "  ")
+	bytes, err := json.MarshalIndent(value, "", "  ")
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	return ioutil.WriteFile(file, bytes, 0644)
+}
+
+func splitPair(str string) (string, string, bool) {
+	// split at first equal sign
+	i := strings.IndexByte(str, '=')
+	if i < 0 {
+		return "", "", false
 	}
 
-	// print
-	fmt.Println(string(bytes))
+	return str[:i], str[i+1:], true
 }
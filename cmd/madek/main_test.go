@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/256dpi/madek"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, item := range []struct {
+		err  error
+		code int
+	}{
+		{err: errors.New("foo"), code: exitError},
+		{err: fmt.Errorf("%w: foo", errUsage), code: exitUsage},
+		{err: madek.ErrInvalidAuthentication, code: exitAuth},
+		{err: &madek.RequestError{Status: 403, Err: madek.ErrAccessForbidden}, code: exitAuth},
+		{err: fmt.Errorf("foo: %w", madek.ErrNotFound), code: exitNotFound},
+		{err: madek.ErrValidationFailed, code: exitError},
+		{err: fmt.Errorf("%w: foo", madek.ErrInvalidReference), code: exitUsage},
+		{err: fmt.Errorf("%w: foo", madek.ErrForeignReference), code: exitUsage},
+		{err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, code: exitNetwork},
+	} {
+		assert.Equal(t, item.code, exitCode(item.err), item.err.Error())
+	}
+}
+
+func TestCheckFlags(t *testing.T) {
+	set := flag.NewFlagSet("madek", flag.ContinueOnError)
+	set.String("profile", "", "")
+	set.String("format", "json", "")
+	set.Bool("json", false, "")
+	assert.NoError(t, set.Parse([]string{"-profile", "test", "-format", "csv"}))
+
+	assert.NoError(t, checkFlags(set, lookup("collection")))
+
+	err := checkFlags(set, lookup("download"))
+	assert.True(t, errors.Is(err, errUsage))
+	assert.Equal(t, "usage error: flag -format does not apply to download", err.Error())
+
+	assert.NoError(t, set.Parse([]string{"-json"}))
+
+	err = checkFlags(set, lookup("collection"))
+	assert.Equal(t, "usage error: flag -json does not apply to collection", err.Error())
+	assert.Equal(t, exitUsage, exitCode(err))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/256dpi/madek"
+)
+
+func migrate(client *madek.Client, args []string) error {
+	// check target
+	if *targetProfile == "" && *targetAddress == "" {
+		return fmt.Errorf("%w: missing target profile or address", errUsage)
+	}
+
+	// prepare target client without falling back to the default profile
+	target, err := newClient(*targetProfile, false, "target-address", "target-username", "MADEK_TARGET_PASSWORD")
+	if err != nil {
+		return err
+	}
+
+	// prepare migration
+	m := &madek.Migration{
+		Source:           client,
+		Target:           target,
+		TargetCollection: *targetCollection,
+		Log: func(msg string) {
+			fmt.Println(msg)
+		},
+	}
+
+	// load previous report
+	if *report != "" {
+		data, err := ioutil.ReadFile(*report)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		} else if err == nil {
+			err = json.Unmarshal(data, &m.Previous)
+			if err != nil {
+				return fmt.Errorf("%s: %w", *report, err)
+			}
+		}
+	}
+
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	// run migration
+	result, runErr := m.Run(coll)
+
+	// write report
+	if *report != "" && result != nil {
+		err = writeJSON(*report, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	// check error
+	if runErr != nil {
+		return runErr
+	}
+
+	// print summary
+	fmt.Printf("Migrated %d media entries to collection %s (%d skipped, %d unmapped)\n",
+		len(coll.MediaEntries)-len(result.Skipped), result.TargetCollection, len(result.Skipped), len(result.Unmapped))
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/256dpi/madek"
+)
+
+func mirror(client *madek.Client, args []string) error {
+	// get directory
+	id, dir := args[0], args[0]
+	if len(args) > 1 {
+		dir = args[1]
+	}
+
+	// parse previews
+	filters, err := parsePreviewFilters(*previews)
+	if err != nil {
+		return err
+	}
+
+	// prepare mirror
+	m := &madek.Mirror{
+		Client:    client,
+		Directory: dir,
+		Previews:  filters,
+		SkipFiles: *skipFiles,
+		Log: func(msg string) {
+			fmt.Println(msg)
+		},
+	}
+
+	// sync mirror
+	manifest, err := m.Sync(id)
+	if err != nil {
+		return err
+	}
+
+	// print summary
+	fmt.Printf("Mirrored %d media entries to %s\n", len(manifest.Entries), dir)
+
+	return nil
+}
+
+func parsePreviewFilters(str string) ([]madek.PreviewFilter, error) {
+	// parse filters
+	var list []madek.PreviewFilter
+	for _, item := range strings.Split(str, ",") {
+		// skip empty items
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		// split type and dimensions
+		typ, dims := item, ""
+		if i := strings.IndexByte(item, ':'); i >= 0 {
+			typ, dims = item[:i], item[i+1:]
+		}
+
+		// set type
+		var filter madek.PreviewFilter
+		if strings.Contains(typ, "/") {
+			filter.ContentType = typ
+			filter.Type = typ[:strings.IndexByte(typ, '/')]
+		} else {
+			filter.Type = typ
+		}
+
+		// parse dimensions
+		if dims != "" {
+			width, height := dims, ""
+			if i := strings.IndexByte(dims, 'x'); i >= 0 {
+				width, height = dims[:i], dims[i+1:]
+			}
+
+			var err error
+			filter.Width, err = parseDimension(width)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid preview filter: %s", errUsage, item)
+			}
+			filter.Height, err = parseDimension(height)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid preview filter: %s", errUsage, item)
+			}
+		}
+
+		// add filter
+		list = append(list, filter)
+	}
+
+	return list, nil
+}
+
+func parseDimension(str string) (int, error) {
+	// check empty
+	if str == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(str)
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/256dpi/madek"
+)
+
+func compileCollection(client *madek.Client, args []string) error {
+	// stream media entries
+	if *format == "ndjson" {
+		return streamMediaEntries(os.Stdout, client, args[0])
+	}
+
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, coll)
+}
+
+func compileEntry(client *madek.Client, args []string) error {
+	// compile media entry
+	entry, err := client.CompileMediaEntry(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, entry)
+}
+
+func show(client *madek.Client, args []string) error {
+	// resolve reference
+	value, err := client.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, value)
+}
+
+func getPerson(client *madek.Client, args []string) error {
+	// get person
+	author, err := client.GetAuthor(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, author)
+}
+
+func getKeyword(client *madek.Client, args []string) error {
+	// get term
+	term, err := client.GetKeywordTerm(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, map[string]string{
+		"id":   args[0],
+		"term": term,
+	})
+}
+
+func getLicense(client *madek.Client, args []string) error {
+	// get label
+	label, err := client.GetLicenseLabel(args[0])
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, map[string]string{
+		"id":    args[0],
+		"label": label,
+	})
+}
+
+func search(client *madek.Client, args []string) error {
+	// prepare query
+	query := madek.Query{
+		Text:  args[0],
+		Limit: *limit,
+	}
+
+	// resolve collection
+	if *collection != "" {
+		var err error
+		query.Collection, err = resolveID(client, *collection, madek.CollectionKind)
+		if err != nil {
+			return err
+		}
+	}
+
+	// parse meta data
+	for _, arg := range args[1:] {
+		key, value, ok := splitPair(arg)
+		if !ok {
+			return fmt.Errorf("%w: invalid meta data filter: %s", errUsage, arg)
+		}
+		if query.MetaData == nil {
+			query.MetaData = map[string]string{}
+		}
+		query.MetaData[key] = value
+	}
+
+	// search media entries
+	list, err := client.SearchMediaEntries(query)
+	if err != nil {
+		return err
+	}
+
+	return output(os.Stdout, list)
+}
+
+func download(client *madek.Client, args []string) error {
+	// compile media entry
+	entry, err := client.CompileMediaEntry(args[0])
+	if err != nil {
+		return err
+	}
+
+	// get file name
+	file := entry.ID
+	if entry.FileName != "" {
+		file = filepath.Base(entry.FileName)
+	}
+	if len(args) > 1 {
+		file = args[1]
+	}
+
+	// open partial file
+	f, err := os.OpenFile(file+".part", os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	// ensure close
+	defer f.Close()
+
+	// download file, a previous partial download is resumed
+	err = client.DownloadMediaFile(entry, f, nil)
+	if err != nil {
+		return err
+	}
+
+	// close file
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	// move file into place
+	err = os.Rename(file+".part", file)
+	if err != nil {
+		return err
+	}
+
+	// print summary
+	fmt.Printf("Downloaded %s to %s\n", entry.ID, file)
+
+	return nil
+}
+
+func export(client *madek.Client, args []string) error {
+	// stream media entries
+	if *format == "ndjson" {
+		return writeFile(args[1:], func(w io.Writer) error {
+			return streamMediaEntries(w, client, args[0])
+		})
+	}
+
+	// compile collection
+	coll, err := client.CompileCollection(args[0])
+	if err != nil {
+		return err
+	}
+
+	return writeFile(args[1:], func(w io.Writer) error {
+		return output(w, coll)
+	})
+}
+
+func streamMediaEntries(w io.Writer, client *madek.Client, id string) error {
+	// get collection url
+	url, err := client.Expand("collection", map[string]string{
+		"id": id,
+	})
+	if err != nil {
+		return err
+	}
+
+	// check collection
+	_, err = client.Fetch(url)
+	if err != nil {
+		return err
+	}
+
+	// get media entries url
+	url, err = client.Expand("media-entries", map[string]string{
+		"collection_id": id,
+	})
+	if err != nil {
+		return err
+	}
+
+	// get media entry ids
+	ids, err := client.Paginate(url, "media-entries", 0).IDs()
+	if err != nil {
+		return err
+	}
+
+	// sort ids
+	sort.Strings(ids)
+
+	// compile and write media entries one per line
+	enc := json.NewEncoder(w)
+	for _, entryID := range ids {
+		entry, err := client.CompileMediaEntry(entryID)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resolveID(client *madek.Client, arg string, kind madek.Kind) (string, error) {
+	// parse reference
+	ref, err := madek.ParseReference(arg)
+	if err != nil {
+		return "", err
+	}
+
+	// check host
+	if !ref.Local(client.URL("")) {
+		return "", fmt.Errorf("%w: %s", madek.ErrForeignReference, arg)
+	}
+
+	// check kind
+	if ref.Kind != "" && ref.Kind != kind {
+		return "", fmt.Errorf("%w: expected a %s but got a %s reference: %s", errUsage, kind, ref.Kind, arg)
+	}
+
+	return ref.ID, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/256dpi/madek"
+)
+
+func serveOAI(client *madek.Client, args []string) error {
+	// check base url
+	if *baseURL == "" {
+		return fmt.Errorf("%w: missing base url", errUsage)
+	}
+
+	// compile collections
+	var colls []*madek.Collection
+	for _, id := range args {
+		coll, err := client.CompileCollection(id)
+		if err != nil {
+			return err
+		}
+		colls = append(colls, coll)
+	}
+
+	// prepare provider
+	provider := &madek.OAIProvider{
+		Collections: func() ([]*madek.Collection, error) {
+			return colls, nil
+		},
+		RepositoryName: *repositoryName,
+		BaseURL:        *baseURL,
+		Address:        client.URL(""),
+	}
+
+	// serve provider
+	fmt.Printf("Serving OAI-PMH on %s\n", *listen)
+
+	return http.ListenAndServe(*listen, provider)
+}
+
+func serve(client *madek.Client, args []string) error {
+	// include permissions to only proxy public previews and files
+	client.IncludePermissions = true
+
+	// prepare server
+	server := &madek.Server{
+		Client:         client,
+		Collections:    args,
+		Interval:       *interval,
+		BaseURL:        *baseURL,
+		RepositoryName: *repositoryName,
+		Log: func(msg string) {
+			fmt.Println(msg)
+		},
+	}
+
+	// load collections
+	err := server.Load()
+	if err != nil {
+		return err
+	}
+
+	// refresh in background
+	go server.Run(nil)
+
+	// serve server
+	fmt.Printf("Serving on %s\n", *listen)
+
+	return http.ListenAndServe(*listen, server)
+}
@@ -0,0 +1,301 @@
+package madek
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Change describes the change of a single field. Scalar fields report the
+// old and new value while list fields report the added and removed items.
+type Change struct {
+	Field   string   `json:"field"`
+	Old     string   `json:"old,omitempty"`
+	New     string   `json:"new,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// An EntryDiff describes the changes of a modified media entry.
+type EntryDiff struct {
+	ID              string     `json:"id"`
+	Title           string     `json:"title,omitempty"`
+	Changes         []Change   `json:"changes,omitempty"`
+	AddedPreviews   []*Preview `json:"added_previews,omitempty"`
+	RemovedPreviews []*Preview `json:"removed_previews,omitempty"`
+}
+
+// A CollectionDiff describes the differences between two compiled
+// collections.
+type CollectionDiff struct {
+	ID       string        `json:"id"`
+	Changes  []Change      `json:"changes,omitempty"`
+	Added    []*MediaEntry `json:"added,omitempty"`
+	Removed  []*MediaEntry `json:"removed,omitempty"`
+	Modified []*EntryDiff  `json:"modified,omitempty"`
+}
+
+// DiffCollections will compare the provided compiled collections and return
+// the added, removed and modified media entries as well as the changes of the
+// collection meta data.
+func DiffCollections(old, new *Collection) *CollectionDiff {
+	// prepare diff
+	diff := &CollectionDiff{
+		ID:      new.ID,
+		Changes: diffMetaData(old.MetaData, new.MetaData),
+	}
+
+	// index entries
+	oldEntries := map[string]*MediaEntry{}
+	for _, entry := range old.MediaEntries {
+		oldEntries[entry.ID] = entry
+	}
+	newEntries := map[string]*MediaEntry{}
+	for _, entry := range new.MediaEntries {
+		newEntries[entry.ID] = entry
+	}
+
+	// find added and modified entries
+	for _, entry := range new.MediaEntries {
+		oldEntry, ok := oldEntries[entry.ID]
+		if !ok {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+
+		entryDiff := DiffMediaEntries(oldEntry, entry)
+		if !entryDiff.Empty() {
+			diff.Modified = append(diff.Modified, entryDiff)
+		}
+	}
+
+	// find removed entries
+	for _, entry := range old.MediaEntries {
+		if _, ok := newEntries[entry.ID]; !ok {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+
+	return diff
+}
+
+// DiffMediaEntries will compare the provided media entries and return the
+// changed fields as well as the added and removed previews.
+func DiffMediaEntries(old, new *MediaEntry) *EntryDiff {
+	// prepare diff
+	diff := &EntryDiff{
+		ID:      new.ID,
+		Title:   titleOf(new.MetaData),
+		Changes: diffMetaData(old.MetaData, new.MetaData),
+	}
+
+	// compare file
+	diff.Changes = appendScalarChange(diff.Changes, "file_id", old.FileID, new.FileID)
+	diff.Changes = appendScalarChange(diff.Changes, "file_name", old.FileName, new.FileName)
+	diff.Changes = appendScalarChange(diff.Changes, "file_type", old.FileType, new.FileType)
+	diff.Changes = appendScalarChange(diff.Changes, "file_size", fmt.Sprint(old.FileSize), fmt.Sprint(new.FileSize))
+
+	// index previews
+	oldPreviews := map[string]bool{}
+	for _, preview := range old.Previews {
+		oldPreviews[preview.ID] = true
+	}
+	newPreviews := map[string]bool{}
+	for _, preview := range new.Previews {
+		newPreviews[preview.ID] = true
+	}
+
+	// compare previews
+	for _, preview := range new.Previews {
+		if !oldPreviews[preview.ID] {
+			diff.AddedPreviews = append(diff.AddedPreviews, preview)
+		}
+	}
+	for _, preview := range old.Previews {
+		if !newPreviews[preview.ID] {
+			diff.RemovedPreviews = append(diff.RemovedPreviews, preview)
+		}
+	}
+
+	return diff
+}
+
+// Empty will return whether the diff contains no changes.
+func (d *EntryDiff) Empty() bool {
+	return len(d.Changes) == 0 && len(d.AddedPreviews) == 0 && len(d.RemovedPreviews) == 0
+}
+
+// Empty will return whether the diff contains no changes.
+func (d *CollectionDiff) Empty() bool {
+	return len(d.Changes) == 0 && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// String will return a human readable report of the diff.
+func (d *CollectionDiff) String() string {
+	// prepare builder
+	var b strings.Builder
+
+	// write collection changes
+	if len(d.Changes) > 0 {
+		fmt.Fprintf(&b, "~ collection %s\n", d.ID)
+		writeChanges(&b, d.Changes)
+	}
+
+	// write added entries
+	for _, entry := range d.Added {
+		fmt.Fprintf(&b, "+ entry %s %q\n", entry.ID, titleOf(entry.MetaData))
+	}
+
+	// write removed entries
+	for _, entry := range d.Removed {
+		fmt.Fprintf(&b, "- entry %s %q\n", entry.ID, titleOf(entry.MetaData))
+	}
+
+	// write modified entries
+	for _, entry := range d.Modified {
+		fmt.Fprintf(&b, "~ entry %s %q\n", entry.ID, entry.Title)
+		writeChanges(&b, entry.Changes)
+
+		// write preview changes
+		if len(entry.AddedPreviews) > 0 || len(entry.RemovedPreviews) > 0 {
+			var list []string
+			for _, preview := range entry.AddedPreviews {
+				list = append(list, fmt.Sprintf("+%s (%s %dx%d)", preview.ID, preview.ContentType, preview.Width, preview.Height))
+			}
+			for _, preview := range entry.RemovedPreviews {
+				list = append(list, fmt.Sprintf("-%s (%s %dx%d)", preview.ID, preview.ContentType, preview.Width, preview.Height))
+			}
+			fmt.Fprintf(&b, "    previews: %s\n", strings.Join(list, " "))
+		}
+	}
+
+	return b.String()
+}
+
+func writeChanges(b *strings.Builder, changes []Change) {
+	for _, change := range changes {
+		// write list change
+		if change.Added != nil || change.Removed != nil {
+			var list []string
+			for _, item := range change.Added {
+				list = append(list, fmt.Sprintf("+%q", item))
+			}
+			for _, item := range change.Removed {
+				list = append(list, fmt.Sprintf("-%q", item))
+			}
+			fmt.Fprintf(b, "    %s: %s\n", change.Field, strings.Join(list, " "))
+			continue
+		}
+
+		// write scalar change
+		fmt.Fprintf(b, "    %s: %q -> %q\n", change.Field, change.Old, change.New)
+	}
+}
+
+func diffMetaData(old, new *MetaData) []Change {
+	// ensure meta data
+	if old == nil {
+		old = &MetaData{}
+	}
+	if new == nil {
+		new = &MetaData{}
+	}
+
+	// compare scalar fields
+	var changes []Change
+	changes = appendScalarChange(changes, "title", old.Title, new.Title)
+	changes = appendScalarChange(changes, "subtitle", old.Subtitle, new.Subtitle)
+	changes = appendScalarChange(changes, "description", old.Description, new.Description)
+	changes = appendScalarChange(changes, "year", old.Year, new.Year)
+	changes = appendScalarChange(changes, "copyright_holder", old.Copyright.Holder, new.Copyright.Holder)
+	changes = appendScalarChange(changes, "copyright_usage", old.Copyright.Usage, new.Copyright.Usage)
+
+	// compare list fields
+	changes = appendListChange(changes, "authors", authorNames(old.Authors), authorNames(new.Authors))
+	changes = appendListChange(changes, "keywords", old.Keywords, new.Keywords)
+	changes = appendListChange(changes, "genres", old.Genres, new.Genres)
+	changes = appendListChange(changes, "licenses", old.Copyright.Licenses, new.Copyright.Licenses)
+	changes = appendListChange(changes, "affiliation", groupNames(old.Affiliation), groupNames(new.Affiliation))
+
+	return changes
+}
+
+func appendScalarChange(changes []Change, field, old, new string) []Change {
+	// check equality
+	if old == new {
+		return changes
+	}
+
+	return append(changes, Change{
+		Field: field,
+		Old:   old,
+		New:   new,
+	})
+}
+
+func appendListChange(changes []Change, field string, old, new []string) []Change {
+	// index lists
+	oldSet := map[string]bool{}
+	for _, item := range old {
+		oldSet[item] = true
+	}
+	newSet := map[string]bool{}
+	for _, item := range new {
+		newSet[item] = true
+	}
+
+	// prepare change
+	change := Change{
+		Field: field,
+	}
+
+	// find added and removed items
+	for _, item := range new {
+		if !oldSet[item] {
+			change.Added = append(change.Added, item)
+		}
+	}
+	for _, item := range old {
+		if !newSet[item] {
+			change.Removed = append(change.Removed, item)
+		}
+	}
+
+	// check change
+	if change.Added == nil && change.Removed == nil {
+		return changes
+	}
+
+	// sort items
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+
+	return append(changes, change)
+}
+
+func authorNames(authors []*Author) []string {
+	list := make([]string, 0, len(authors))
+	for _, author := range authors {
+		list = append(list, author.Name())
+	}
+
+	return list
+}
+
+func groupNames(groups []*Group) []string {
+	list := make([]string, 0, len(groups))
+	for _, group := range groups {
+		list = append(list, group.Name)
+	}
+
+	return list
+}
+
+func titleOf(metaData *MetaData) string {
+	// check meta data
+	if metaData == nil {
+		return ""
+	}
+
+	return metaData.Title
+}
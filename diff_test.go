@@ -0,0 +1,91 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCollections(t *testing.T) {
+	old := &Collection{
+		ID: "c1",
+		MetaData: &MetaData{
+			Title: "Old",
+		},
+		MediaEntries: []*MediaEntry{
+			{
+				ID: "e1",
+				MetaData: &MetaData{
+					Title:    "Image",
+					Authors:  []*Author{{ID: "a1", FirstName: "Jane", LastName: "Doe"}},
+					Keywords: []string{"Design", "Light"},
+				},
+				Previews: []*Preview{
+					{ID: "p1", ContentType: "image/jpeg", Width: 100, Height: 56},
+				},
+			},
+			{
+				ID:       "e2",
+				MetaData: &MetaData{Title: "Removed"},
+			},
+			{
+				ID:       "e3",
+				MetaData: &MetaData{Title: "Unchanged"},
+			},
+		},
+	}
+
+	new := &Collection{
+		ID: "c1",
+		MetaData: &MetaData{
+			Title: "New",
+		},
+		MediaEntries: []*MediaEntry{
+			{
+				ID: "e1",
+				MetaData: &MetaData{
+					Title:     "Image",
+					Authors:   []*Author{{ID: "a2", FirstName: "John", LastName: "Doe"}},
+					Keywords:  []string{"Design", "Space"},
+					Copyright: Copyright{Licenses: []string{"CC BY"}},
+				},
+				Previews: []*Preview{
+					{ID: "p2", ContentType: "image/jpeg", Width: 620, Height: 348},
+				},
+			},
+			{
+				ID:       "e3",
+				MetaData: &MetaData{Title: "Unchanged"},
+			},
+			{
+				ID:       "e4",
+				MetaData: &MetaData{Title: "Added"},
+			},
+		},
+	}
+
+	diff := DiffCollections(old, new)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []Change{{Field: "title", Old: "Old", New: "New"}}, diff.Changes)
+	assert.Len(t, diff.Added, 1)
+	assert.Len(t, diff.Removed, 1)
+	assert.Len(t, diff.Modified, 1)
+	assert.Equal(t, []Change{
+		{Field: "authors", Added: []string{"John Doe"}, Removed: []string{"Jane Doe"}},
+		{Field: "keywords", Added: []string{"Space"}, Removed: []string{"Light"}},
+		{Field: "licenses", Added: []string{"CC BY"}},
+	}, diff.Modified[0].Changes)
+
+	assert.Equal(t, `~ collection c1
+    title: "Old" -> "New"
++ entry e4 "Added"
+- entry e2 "Removed"
+~ entry e1 "Image"
+    authors: +"John Doe" -"Jane Doe"
+    keywords: +"Space" -"Light"
+    licenses: +"CC BY"
+    previews: +p2 (image/jpeg 620x348) -p1 (image/jpeg 100x56)
+`, diff.String())
+
+	assert.True(t, DiffCollections(new, new).Empty())
+}
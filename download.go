@@ -0,0 +1,174 @@
+package madek
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrSizeMismatch is returned when the size of a downloaded file does not
+// match the expected size.
+var ErrSizeMismatch = errors.New("size mismatch")
+
+// ErrRangeNotSatisfiable is returned when the server rejects the range of a
+// resumed download e.g. because the partial data is larger than the file.
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// ErrRangeMismatch is returned when the server answers a resumed download
+// with a range that does not start at the requested offset.
+var ErrRangeMismatch = errors.New("range mismatch")
+
+// ErrRangeIgnored is returned when the server ignores the range of a resumed
+// download and the writer cannot be truncated to restart the download.
+var ErrRangeIgnored = errors.New("range ignored")
+
+// A ProgressFunc is called with the number of bytes transferred so far and the
+// total number of bytes. The total is zero if it is not known.
+type ProgressFunc func(done, total int64)
+
+// DownloadMediaFile will download the original file of the provided media
+// entry to the provided writer. If the writer is an io.Seeker that already
+// contains data (e.g. a file opened for appending), the download is resumed
+// at its end using an HTTP range request. The optional progress function is
+// called after each write. The final size is checked against the file size of
+// the media entry.
+func (c *Client) DownloadMediaFile(entry *MediaEntry, w io.Writer, progress ProgressFunc) error {
+	// check url
+	if entry.StreamURL == "" {
+		return fmt.Errorf("missing stream url for media entry: %s", entry.ID)
+	}
+
+	return c.download(entry.StreamURL, w, entry.FileSize, progress)
+}
+
+func (c *Client) download(url string, w io.Writer, size int64, progress ProgressFunc) error {
+	// determine offset, writers that cannot seek (e.g. pipes) are written
+	// from the start
+	var offset int64
+	if seeker, ok := w.(io.Seeker); ok {
+		if end, err := seeker.Seek(0, io.SeekEnd); err == nil {
+			offset = end
+		}
+	}
+
+	// check offset
+	if size > 0 && offset > size {
+		return ErrSizeMismatch
+	} else if size > 0 && offset == size {
+		if progress != nil {
+			progress(offset, size)
+		}
+		return nil
+	}
+
+	// prepare request
+	req, err := c.newRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	// request remaining range
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	// perform request
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// ensure body close
+	defer res.Body.Close()
+
+	// check status code
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		// check that the range starts at the offset
+		var start int64
+		_, err = fmt.Sscanf(res.Header.Get("Content-Range"), "bytes %d-", &start)
+		if err != nil || start != offset {
+			return ErrRangeMismatch
+		}
+	case http.StatusOK:
+		// restart download if range has been ignored
+		if offset > 0 {
+			err = truncate(w)
+			if err != nil {
+				return err
+			}
+			offset = 0
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		return ErrRangeNotSatisfiable
+	default:
+		return statusError(res.StatusCode)
+	}
+
+	// determine total
+	total := size
+	if total <= 0 && res.ContentLength >= 0 {
+		total = offset + res.ContentLength
+	}
+
+	// copy data
+	n, err := io.Copy(&progressWriter{
+		writer:   w,
+		done:     offset,
+		total:    total,
+		progress: progress,
+	}, res.Body)
+	if err != nil {
+		return err
+	}
+
+	// check size
+	if size > 0 && offset+n != size {
+		return ErrSizeMismatch
+	}
+
+	return nil
+}
+
+func truncate(w io.Writer) error {
+	// check writer
+	tw, ok := w.(interface {
+		io.Seeker
+		Truncate(size int64) error
+	})
+	if !ok {
+		return ErrRangeIgnored
+	}
+
+	// truncate writer
+	err := tw.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	// rewind writer
+	_, err = tw.Seek(0, io.SeekStart)
+
+	return err
+}
+
+type progressWriter struct {
+	writer   io.Writer
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	// write data
+	n, err := w.writer.Write(p)
+	w.done += int64(n)
+
+	// report progress
+	if w.progress != nil {
+		w.progress(w.done, w.total)
+	}
+
+	return n, err
+}
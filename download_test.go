@@ -0,0 +1,149 @@
+package madek
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadMediaFile(t *testing.T) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, strings.NewReader("Hello World!"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user", "pass")
+
+	entry := &MediaEntry{
+		ID:        "e1",
+		FileSize:  12,
+		StreamURL: server.URL + "/api/media-files/f1/data-stream",
+	}
+
+	var buf bytes.Buffer
+	var reports [][2]int64
+	err := client.DownloadMediaFile(entry, &buf, func(done, total int64) {
+		reports = append(reports, [2]int64{done, total})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World!", buf.String())
+	assert.Equal(t, [][2]int64{{12, 12}}, reports)
+	assert.Equal(t, []string{""}, ranges)
+
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Hello "), 0644))
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+
+	err = client.DownloadMediaFile(entry, file, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, []string{"", "bytes=6-"}, ranges)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World!", string(data))
+
+	entry.FileSize = 13
+	err = client.DownloadMediaFile(entry, &bytes.Buffer{}, nil)
+	assert.Equal(t, ErrSizeMismatch, err)
+
+	err = NewClient(server.URL, "user", "wrong").DownloadMediaFile(entry, &bytes.Buffer{}, nil)
+	assert.Equal(t, ErrInvalidAuthentication, err)
+}
+
+func TestDownloadResumeFallbacks(t *testing.T) {
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte("Hello World!"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", "")
+
+	entry := &MediaEntry{
+		ID:        "e1",
+		FileSize:  12,
+		StreamURL: server.URL + "/api/media-files/f1/data-stream",
+	}
+
+	path := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Hello "), 0644))
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+
+	var reports [][2]int64
+	err = client.DownloadMediaFile(entry, file, func(done, total int64) {
+		reports = append(reports, [2]int64{done, total})
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, [][2]int64{{12, 12}}, reports)
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World!", string(data))
+
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+
+	done := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(reader)
+		done <- string(data)
+	}()
+
+	err = client.DownloadMediaFile(entry, writer, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "Hello World!", <-done)
+
+	status = http.StatusRequestedRangeNotSatisfiable
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Hello "), 0644))
+
+	file, err = os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+
+	err = client.DownloadMediaFile(entry, file, nil)
+	assert.Equal(t, ErrRangeNotSatisfiable, err)
+	assert.NoError(t, file.Close())
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-11/12")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("Hello World!"))
+	})
+
+	file, err = os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+
+	err = client.DownloadMediaFile(entry, file, nil)
+	assert.Equal(t, ErrRangeMismatch, err)
+	assert.NoError(t, file.Close())
+
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ", string(data))
+}
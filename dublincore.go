@@ -0,0 +1,108 @@
+package madek
+
+import (
+	"encoding/xml"
+)
+
+// The namespaces and schema of oai_dc records.
+const (
+	OAIDCNamespace = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	OAIDCSchema    = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	DCNamespace    = "http://purl.org/dc/elements/1.1/"
+)
+
+// A DCRecord is a simple Dublin Core record in the oai_dc format.
+type DCRecord struct {
+	XMLName        xml.Name `xml:"oai_dc:dc"`
+	NamespaceOAIDC string   `xml:"xmlns:oai_dc,attr"`
+	NamespaceDC    string   `xml:"xmlns:dc,attr"`
+	NamespaceXSI   string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Title          []string `xml:"dc:title"`
+	Creator        []string `xml:"dc:creator"`
+	Subject        []string `xml:"dc:subject"`
+	Description    []string `xml:"dc:description"`
+	Contributor    []string `xml:"dc:contributor"`
+	Date           []string `xml:"dc:date"`
+	Type           []string `xml:"dc:type"`
+	Format         []string `xml:"dc:format"`
+	Identifier     []string `xml:"dc:identifier"`
+	Rights         []string `xml:"dc:rights"`
+}
+
+// NewDCRecord will map the provided meta data to a Dublin Core record.
+// Authors become creators in the form "Last, First", keywords subjects,
+// genres types, affiliations contributors and the copyright notice, usage
+// and licenses rights statements.
+func NewDCRecord(md *MetaData) *DCRecord {
+	// prepare record
+	record := &DCRecord{
+		NamespaceOAIDC: OAIDCNamespace,
+		NamespaceDC:    DCNamespace,
+		NamespaceXSI:   "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: OAIDCNamespace + " " + OAIDCSchema,
+	}
+
+	// check meta data
+	if md == nil {
+		return record
+	}
+
+	// map creators
+	for _, author := range md.Authors {
+		record.Creator = appendNonEmpty(record.Creator, citationName(author))
+	}
+
+	// map contributors
+	for _, group := range md.Affiliation {
+		record.Contributor = appendNonEmpty(record.Contributor, group.Name)
+	}
+
+	// map other fields
+	record.Title = appendNonEmpty(record.Title, md.Title)
+	record.Description = appendNonEmpty(record.Description, md.Subtitle, md.Description)
+	record.Subject = appendNonEmpty(record.Subject, md.Keywords...)
+	record.Type = appendNonEmpty(record.Type, md.Genres...)
+	record.Date = appendNonEmpty(record.Date, md.Year)
+	record.Rights = appendNonEmpty(record.Rights, md.Copyright.Holder, md.Copyright.Usage)
+	record.Rights = appendNonEmpty(record.Rights, md.Copyright.Licenses...)
+
+	return record
+}
+
+// DublinCore will return the Dublin Core record of the media entry. The web
+// page on the Madek instance with the provided address is added as identifier
+// if the address is not empty.
+func (e *MediaEntry) DublinCore(address string) *DCRecord {
+	// map meta data
+	record := NewDCRecord(e.MetaData)
+	record.Format = appendNonEmpty(record.Format, e.FileType)
+	if address != "" {
+		record.Identifier = append(record.Identifier, WebURL(address, MediaEntryKind, e.ID))
+	}
+
+	return record
+}
+
+// DublinCore will return the Dublin Core record of the collection. The web
+// page on the Madek instance with the provided address is added as identifier
+// if the address is not empty.
+func (c *Collection) DublinCore(address string) *DCRecord {
+	// map meta data
+	record := NewDCRecord(c.MetaData)
+	if address != "" {
+		record.Identifier = append(record.Identifier, WebURL(address, CollectionKind, c.ID))
+	}
+
+	return record
+}
+
+func appendNonEmpty(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
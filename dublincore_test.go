@@ -0,0 +1,50 @@
+package madek
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDublinCore(t *testing.T) {
+	entry := &MediaEntry{
+		ID:       "e1",
+		FileType: "image/jpeg",
+		MetaData: &MetaData{
+			Title:       "Title",
+			Subtitle:    "Subtitle",
+			Authors:     []*Author{{FirstName: "Jane", LastName: "Doe"}, {LastName: "Studio"}},
+			Keywords:    []string{"Design", "Art"},
+			Genres:      []string{"Photography"},
+			Year:        "2016",
+			Affiliation: []*Group{{Name: "Department"}},
+			Copyright: Copyright{
+				Holder:   "Holder",
+				Licenses: []string{"CC BY"},
+			},
+		},
+	}
+
+	data, err := xml.MarshalIndent(entry.DublinCore("https://madek.example.com"), "", "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, `<oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.openarchives.org/OAI/2.0/oai_dc/ http://www.openarchives.org/OAI/2.0/oai_dc.xsd">
+  <dc:title>Title</dc:title>
+  <dc:creator>Doe, Jane</dc:creator>
+  <dc:creator>Studio</dc:creator>
+  <dc:subject>Design</dc:subject>
+  <dc:subject>Art</dc:subject>
+  <dc:description>Subtitle</dc:description>
+  <dc:contributor>Department</dc:contributor>
+  <dc:date>2016</dc:date>
+  <dc:type>Photography</dc:type>
+  <dc:format>image/jpeg</dc:format>
+  <dc:identifier>https://madek.example.com/entries/e1</dc:identifier>
+  <dc:rights>Holder</dc:rights>
+  <dc:rights>CC BY</dc:rights>
+</oai_dc:dc>`, string(data))
+
+	record := (&Collection{ID: "c1"}).DublinCore("")
+	assert.Empty(t, record.Title)
+	assert.Empty(t, record.Identifier)
+}
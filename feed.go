@@ -0,0 +1,502 @@
+package madek
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The supported feed formats.
+const (
+	FeedAtom = "atom"
+	FeedRSS  = "rss"
+	FeedJSON = "json"
+)
+
+// The namespaces and versions of the feed formats.
+const (
+	AtomNamespace   = "http://www.w3.org/2005/Atom"
+	JSONFeedVersion = "https://jsonfeed.org/version/1.1"
+)
+
+// An AtomFeed is an Atom 1.0 feed.
+type AtomFeed struct {
+	XMLName  xml.Name      `xml:"feed"`
+	XMLNS    string        `xml:"xmlns,attr"`
+	ID       string        `xml:"id"`
+	Title    string        `xml:"title"`
+	Subtitle string        `xml:"subtitle,omitempty"`
+	Updated  string        `xml:"updated"`
+	Links    []*AtomLink   `xml:"link"`
+	Authors  []*AtomPerson `xml:"author"`
+	Entries  []*AtomEntry  `xml:"entry"`
+}
+
+// An AtomEntry is an entry of an Atom feed.
+type AtomEntry struct {
+	ID         string          `xml:"id"`
+	Title      string          `xml:"title"`
+	Summary    string          `xml:"summary,omitempty"`
+	Published  string          `xml:"published,omitempty"`
+	Updated    string          `xml:"updated"`
+	Content    *AtomContent    `xml:"content"`
+	Links      []*AtomLink     `xml:"link"`
+	Authors    []*AtomPerson   `xml:"author"`
+	Categories []*AtomCategory `xml:"category"`
+}
+
+// An AtomContent is the content of an Atom entry that links to a media
+// object.
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+// An AtomLink is a link of an Atom feed or entry.
+type AtomLink struct {
+	Rel    string `xml:"rel,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+// An AtomPerson is an author of an Atom feed or entry.
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// An AtomCategory is a category of an Atom entry.
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// An RSSFeed is an RSS 2.0 feed. Authors are encoded as Dublin Core creators
+// as RSS only supports email addresses.
+type RSSFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	XMLNSDC string      `xml:"xmlns:dc,attr"`
+	Channel *RSSChannel `xml:"channel"`
+}
+
+// An RSSChannel is the channel of an RSS feed.
+type RSSChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*RSSItem `xml:"item"`
+}
+
+// An RSSItem is an item of an RSS channel.
+type RSSItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	GUID        *RSSGUID      `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Creators    []string      `xml:"dc:creator"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *RSSEnclosure `xml:"enclosure"`
+}
+
+// An RSSGUID is the unique identifier of an RSS item.
+type RSSGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// An RSSEnclosure is a media object attached to an RSS item.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// A JSONFeed is a JSON Feed 1.1 document.
+type JSONFeed struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	HomePageURL string          `json:"home_page_url,omitempty"`
+	FeedURL     string          `json:"feed_url,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Items       []*JSONFeedItem `json:"items"`
+}
+
+// A JSONFeedItem is an item of a JSON Feed.
+type JSONFeedItem struct {
+	ID            string                `json:"id"`
+	URL           string                `json:"url,omitempty"`
+	Title         string                `json:"title,omitempty"`
+	ContentText   string                `json:"content_text"`
+	Image         string                `json:"image,omitempty"`
+	DatePublished string                `json:"date_published,omitempty"`
+	DateModified  string                `json:"date_modified,omitempty"`
+	Authors       []*JSONFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string              `json:"tags,omitempty"`
+	Attachments   []*JSONFeedAttachment `json:"attachments,omitempty"`
+}
+
+// A JSONFeedAuthor is an author of a JSON Feed item.
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// A JSONFeedAttachment is a media object attached to a JSON Feed item.
+type JSONFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+// A FeedGenerator converts compiled collections into Atom, RSS 2.0 and JSON
+// Feed documents. Media entries are ordered by their creation time with the
+// newest first and get an enclosure that links their largest public video,
+// audio or image preview or, if there is none, their public original file.
+// As Madek does not report the size of previews, only enclosed original files
+// carry a length. Media entries whose permissions have been compiled and are
+// not public are omitted.
+type FeedGenerator struct {
+	// The URL the feed is published at. It is used as the self link and, if
+	// set, as the id of Atom feeds.
+	FeedURL string
+
+	// The address of the Madek instance used to link the web pages of the
+	// collection and its media entries. If empty, Atom entries link to their
+	// enclosure instead.
+	Address string
+
+	// The maximum number of items. Zero means no limit.
+	Limit int
+}
+
+// Atom will convert the provided collection into an Atom feed.
+func (g *FeedGenerator) Atom(coll *Collection) *AtomFeed {
+	// prepare feed
+	feed := &AtomFeed{
+		XMLNS:    AtomNamespace,
+		ID:       g.FeedURL,
+		Title:    feedTitle(coll.ID, coll.MetaData),
+		Subtitle: feedSummary(coll.MetaData),
+		Updated:  schemaTime(g.updated(coll)),
+	}
+
+	// add links
+	if feed.ID == "" {
+		feed.ID = feedID(CollectionKind, coll.ID)
+	}
+	if g.FeedURL != "" {
+		feed.Links = append(feed.Links, &AtomLink{Rel: "self", Href: g.FeedURL, Type: "application/atom+xml"})
+	}
+	if g.Address != "" {
+		feed.Links = append(feed.Links, &AtomLink{Rel: "alternate", Href: WebURL(g.Address, CollectionKind, coll.ID), Type: "text/html"})
+	}
+
+	// add authors
+	if coll.MetaData != nil {
+		for _, name := range authorNames(coll.MetaData.Authors) {
+			feed.Authors = append(feed.Authors, &AtomPerson{Name: name})
+		}
+	}
+
+	// add entries
+	for _, entry := range g.entries(coll) {
+		item := &AtomEntry{
+			ID:        feedID(MediaEntryKind, entry.ID),
+			Title:     feedTitle(entry.ID, entry.MetaData),
+			Summary:   feedSummary(entry.MetaData),
+			Published: schemaTime(entry.CreatedAt),
+			Updated:   schemaTime(entry.ModifiedAt()),
+		}
+		enclosure := feedEnclosure(entry)
+		if g.Address != "" {
+			item.Links = append(item.Links, &AtomLink{Rel: "alternate", Href: WebURL(g.Address, MediaEntryKind, entry.ID), Type: "text/html"})
+		} else if enclosure != nil {
+			item.Content = &AtomContent{Type: enclosure.Type, Src: enclosure.URL}
+			item.Links = append(item.Links, &AtomLink{Rel: "alternate", Href: enclosure.URL, Type: enclosure.Type})
+		}
+		if enclosure != nil {
+			item.Links = append(item.Links, &AtomLink{Rel: "enclosure", Href: enclosure.URL, Type: enclosure.Type, Length: enclosure.Length})
+		}
+		if md := entry.MetaData; md != nil {
+			for _, name := range authorNames(md.Authors) {
+				item.Authors = append(item.Authors, &AtomPerson{Name: name})
+			}
+			for _, keyword := range md.Keywords {
+				item.Categories = append(item.Categories, &AtomCategory{Term: keyword})
+			}
+		}
+		feed.Entries = append(feed.Entries, item)
+	}
+
+	return feed
+}
+
+// RSS will convert the provided collection into an RSS 2.0 feed.
+func (g *FeedGenerator) RSS(coll *Collection) *RSSFeed {
+	// prepare channel
+	channel := &RSSChannel{
+		Title:         feedTitle(coll.ID, coll.MetaData),
+		Link:          g.FeedURL,
+		Description:   feedSummary(coll.MetaData),
+		LastBuildDate: rssTime(g.updated(coll)),
+	}
+	if g.Address != "" {
+		channel.Link = WebURL(g.Address, CollectionKind, coll.ID)
+	}
+
+	// add items
+	for _, entry := range g.entries(coll) {
+		item := &RSSItem{
+			Title:       feedTitle(entry.ID, entry.MetaData),
+			Description: feedSummary(entry.MetaData),
+			GUID:        &RSSGUID{Value: feedID(MediaEntryKind, entry.ID)},
+			PubDate:     rssTime(entry.CreatedAt),
+		}
+		if g.Address != "" {
+			item.Link = WebURL(g.Address, MediaEntryKind, entry.ID)
+			item.GUID = &RSSGUID{IsPermaLink: true, Value: item.Link}
+		}
+		if enclosure := feedEnclosure(entry); enclosure != nil {
+			item.Enclosure = &RSSEnclosure{URL: enclosure.URL, Length: enclosure.Length, Type: enclosure.Type}
+		}
+		if md := entry.MetaData; md != nil {
+			item.Creators = authorNames(md.Authors)
+			item.Categories = md.Keywords
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return &RSSFeed{
+		Version: "2.0",
+		XMLNSDC: DCNamespace,
+		Channel: channel,
+	}
+}
+
+// JSONFeed will convert the provided collection into a JSON Feed.
+func (g *FeedGenerator) JSONFeed(coll *Collection) *JSONFeed {
+	// prepare feed
+	feed := &JSONFeed{
+		Version:     JSONFeedVersion,
+		Title:       feedTitle(coll.ID, coll.MetaData),
+		FeedURL:     g.FeedURL,
+		Description: feedSummary(coll.MetaData),
+		Items:       []*JSONFeedItem{},
+	}
+	if g.Address != "" {
+		feed.HomePageURL = WebURL(g.Address, CollectionKind, coll.ID)
+	}
+
+	// add items
+	for _, entry := range g.entries(coll) {
+		item := &JSONFeedItem{
+			ID:            feedID(MediaEntryKind, entry.ID),
+			Title:         feedTitle(entry.ID, entry.MetaData),
+			ContentText:   feedSummary(entry.MetaData),
+			DatePublished: schemaTime(entry.CreatedAt),
+			DateModified:  schemaTime(entry.ModifiedAt()),
+		}
+		if g.Address != "" {
+			item.URL = WebURL(g.Address, MediaEntryKind, entry.ID)
+		}
+		if images := (&MediaEntry{Previews: entry.PublicPreviews()}).Renditions().Images(); len(images) > 0 {
+			item.Image = images[0].Largest().URL
+		}
+		if enclosure := feedEnclosure(entry); enclosure != nil {
+			item.Attachments = []*JSONFeedAttachment{{URL: enclosure.URL, MimeType: enclosure.Type, SizeInBytes: enclosure.Length}}
+		}
+		if md := entry.MetaData; md != nil {
+			for _, name := range authorNames(md.Authors) {
+				item.Authors = append(item.Authors, &JSONFeedAuthor{Name: name})
+			}
+			item.Tags = md.Keywords
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	return feed
+}
+
+// Write will convert the provided collection into a feed of the specified
+// format and write it to the provided writer.
+func (g *FeedGenerator) Write(w io.Writer, coll *Collection, format string) error {
+	// encode feed
+	switch format {
+	case FeedAtom:
+		return writeXML(w, g.Atom(coll))
+	case FeedRSS:
+		return writeXML(w, g.RSS(coll))
+	case FeedJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g.JSONFeed(coll))
+	default:
+		return fmt.Errorf("unknown feed format: %s", format)
+	}
+}
+
+func (g *FeedGenerator) entries(coll *Collection) []*MediaEntry {
+	// collect public entries
+	var list []*MediaEntry
+	for _, entry := range coll.MediaEntries {
+		if entry.Permissions == nil || entry.Permissions.Public {
+			list = append(list, entry)
+		}
+	}
+
+	// sort by creation
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+
+	// apply limit
+	if g.Limit > 0 && len(list) > g.Limit {
+		list = list[:g.Limit]
+	}
+
+	return list
+}
+
+func (g *FeedGenerator) updated(coll *Collection) time.Time {
+	// find latest modification
+	updated := coll.ModifiedAt()
+	for _, entry := range g.entries(coll) {
+		updated = latestTime(updated, entry.ModifiedAt())
+	}
+
+	return updated
+}
+
+// A FeedHandler is a http.Handler that serves a collection as a feed. The
+// format is selected by the "format" query parameter or the extension of the
+// request path (".atom", ".rss" or ".json") and defaults to Atom.
+type FeedHandler struct {
+	// The function that returns the served collection.
+	Collection func() (*Collection, error)
+
+	// The generator used to convert the collection.
+	Generator *FeedGenerator
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// get format
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = strings.TrimPrefix(path.Ext(r.URL.Path), ".")
+	}
+	if format == "" {
+		format = FeedAtom
+	}
+
+	// get content type
+	contentType, ok := map[string]string{
+		FeedAtom: "application/atom+xml; charset=utf-8",
+		FeedRSS:  "application/rss+xml; charset=utf-8",
+		FeedJSON: "application/feed+json; charset=utf-8",
+	}[format]
+	if !ok {
+		http.Error(w, "unknown feed format", http.StatusNotFound)
+		return
+	}
+
+	// get collection
+	coll, err := h.Collection()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write feed
+	w.Header().Set("Content-Type", contentType)
+	_ = h.Generator.Write(w, coll, format)
+}
+
+type enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+func feedEnclosure(entry *MediaEntry) *enclosure {
+	// get renditions
+	set := (&MediaEntry{Previews: entry.PublicPreviews()}).Renditions()
+
+	// select largest video, audio or image preview
+	for _, groups := range []RenditionSet{set.Videos(), set.Filter("audio"), set.Images()} {
+		if len(groups) > 0 {
+			preview := groups[0].Largest()
+			return &enclosure{URL: preview.URL, Type: preview.ContentType}
+		}
+	}
+
+	// otherwise use public original file
+	if entry.StreamURL != "" && entry.PublicFile() {
+		return &enclosure{URL: entry.StreamURL, Type: entry.FileType, Length: entry.FileSize}
+	}
+
+	return nil
+}
+
+func feedTitle(id string, md *MetaData) string {
+	// check title
+	if md == nil || md.Title == "" {
+		return id
+	}
+
+	return md.Title
+}
+
+func feedSummary(md *MetaData) string {
+	// check meta data
+	if md == nil {
+		return ""
+	}
+
+	// prefer description
+	if md.Description != "" {
+		return md.Description
+	}
+
+	return md.Subtitle
+}
+
+func feedID(kind Kind, id string) string {
+	return "urn:madek:" + string(kind) + ":" + id
+}
+
+func rssTime(t time.Time) string {
+	// check zero
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+func writeXML(w io.Writer, value interface{}) error {
+	// write header
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	// encode value
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,232 @@
+package madek
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeedCollection() *Collection {
+	updatedAt := time.Date(2016, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	return &Collection{
+		ID:        "c1",
+		UpdatedAt: &updatedAt,
+		MetaData:  &MetaData{Title: "Collection", Description: "Description"},
+		MediaEntries: []*MediaEntry{
+			{
+				ID:        "e1",
+				CreatedAt: time.Date(2016, 5, 25, 9, 0, 0, 0, time.UTC),
+				MetaData: &MetaData{
+					Title:    "Image",
+					Authors:  []*Author{{FirstName: "Jane", LastName: "Doe"}},
+					Keywords: []string{"Design"},
+				},
+				Previews: []*Preview{
+					{ID: "p1", Type: "image", ContentType: "image/jpeg", Width: 100, URL: "https://example.com/p1"},
+					{ID: "p2", Type: "image", ContentType: "image/jpeg", Width: 620, URL: "https://example.com/p2"},
+				},
+			},
+			{
+				ID:          "e2",
+				CreatedAt:   time.Date(2016, 5, 26, 9, 0, 0, 0, time.UTC),
+				MetaData:    &MetaData{Title: "Video"},
+				Permissions: &Permissions{Public: true},
+				Previews: []*Preview{
+					{ID: "p3", Type: "image", ContentType: "image/jpeg", Width: 1920, URL: "https://example.com/p3"},
+					{ID: "p4", Type: "video", ContentType: "video/mp4", Width: 1920, URL: "https://example.com/p4"},
+				},
+			},
+			{
+				ID:          "e3",
+				CreatedAt:   time.Date(2016, 5, 27, 9, 0, 0, 0, time.UTC),
+				Permissions: &Permissions{},
+			},
+		},
+	}
+}
+
+func TestFeedGeneratorAtom(t *testing.T) {
+	generator := &FeedGenerator{
+		FeedURL: "https://example.com/feed.atom",
+		Address: "https://madek.example.com",
+	}
+
+	var buf bytes.Buffer
+	err := generator.Write(&buf, testFeedCollection(), FeedAtom)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>https://example.com/feed.atom</id>
+  <title>Collection</title>
+  <subtitle>Description</subtitle>
+  <updated>2016-06-01T10:00:00Z</updated>
+  <link rel="self" href="https://example.com/feed.atom" type="application/atom+xml"></link>
+  <link rel="alternate" href="https://madek.example.com/sets/c1" type="text/html"></link>
+  <entry>
+    <id>urn:madek:media-entry:e2</id>
+    <title>Video</title>
+    <published>2016-05-26T09:00:00Z</published>
+    <updated>2016-05-26T09:00:00Z</updated>
+    <link rel="alternate" href="https://madek.example.com/entries/e2" type="text/html"></link>
+    <link rel="enclosure" href="https://example.com/p4" type="video/mp4"></link>
+  </entry>
+  <entry>
+    <id>urn:madek:media-entry:e1</id>
+    <title>Image</title>
+    <published>2016-05-25T09:00:00Z</published>
+    <updated>2016-05-25T09:00:00Z</updated>
+    <link rel="alternate" href="https://madek.example.com/entries/e1" type="text/html"></link>
+    <link rel="enclosure" href="https://example.com/p2" type="image/jpeg"></link>
+    <author>
+      <name>Jane Doe</name>
+    </author>
+    <category term="Design"></category>
+  </entry>
+</feed>
+`, buf.String())
+}
+
+func TestFeedGeneratorRSS(t *testing.T) {
+	generator := &FeedGenerator{
+		Address: "https://madek.example.com",
+		Limit:   1,
+	}
+
+	var buf bytes.Buffer
+	err := generator.Write(&buf, testFeedCollection(), FeedRSS)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Collection</title>
+    <link>https://madek.example.com/sets/c1</link>
+    <description>Description</description>
+    <lastBuildDate>Wed, 01 Jun 2016 10:00:00 +0000</lastBuildDate>
+    <item>
+      <title>Video</title>
+      <link>https://madek.example.com/entries/e2</link>
+      <guid isPermaLink="true">https://madek.example.com/entries/e2</guid>
+      <pubDate>Thu, 26 May 2016 09:00:00 +0000</pubDate>
+      <enclosure url="https://example.com/p4" length="0" type="video/mp4"></enclosure>
+    </item>
+  </channel>
+</rss>
+`, buf.String())
+}
+
+func TestFeedGeneratorJSON(t *testing.T) {
+	generator := &FeedGenerator{}
+
+	var buf bytes.Buffer
+	err := generator.Write(&buf, testFeedCollection(), FeedJSON)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "https://jsonfeed.org/version/1.1",
+		"title": "Collection",
+		"description": "Description",
+		"items": [
+			{
+				"id": "urn:madek:media-entry:e2",
+				"title": "Video",
+				"content_text": "",
+				"image": "https://example.com/p3",
+				"date_published": "2016-05-26T09:00:00Z",
+				"date_modified": "2016-05-26T09:00:00Z",
+				"attachments": [{"url": "https://example.com/p4", "mime_type": "video/mp4"}]
+			},
+			{
+				"id": "urn:madek:media-entry:e1",
+				"title": "Image",
+				"content_text": "",
+				"image": "https://example.com/p2",
+				"date_published": "2016-05-25T09:00:00Z",
+				"date_modified": "2016-05-25T09:00:00Z",
+				"authors": [{"name": "Jane Doe"}],
+				"tags": ["Design"],
+				"attachments": [{"url": "https://example.com/p2", "mime_type": "image/jpeg"}]
+			}
+		]
+	}`, buf.String())
+
+	err = generator.Write(&buf, testFeedCollection(), "foo")
+	assert.Error(t, err)
+}
+
+func TestFeedGeneratorFallbacks(t *testing.T) {
+	coll := testFeedCollection()
+	coll.MediaEntries = append(coll.MediaEntries, &MediaEntry{
+		ID:        "e4",
+		CreatedAt: time.Date(2016, 5, 28, 9, 0, 0, 0, time.UTC),
+		FileType:  "audio/mpeg",
+		FileSize:  1234,
+		StreamURL: "https://example.com/f4",
+	})
+	coll.MediaEntries = coll.MediaEntries[1:]
+
+	generator := &FeedGenerator{}
+
+	var buf bytes.Buffer
+	err := generator.Write(&buf, coll, FeedAtom)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <id>urn:madek:collection:c1</id>
+  <title>Collection</title>
+  <subtitle>Description</subtitle>
+  <updated>2016-06-01T10:00:00Z</updated>
+  <entry>
+    <id>urn:madek:media-entry:e4</id>
+    <title>e4</title>
+    <published>2016-05-28T09:00:00Z</published>
+    <updated>2016-05-28T09:00:00Z</updated>
+    <content type="audio/mpeg" src="https://example.com/f4"></content>
+    <link rel="alternate" href="https://example.com/f4" type="audio/mpeg"></link>
+    <link rel="enclosure" href="https://example.com/f4" type="audio/mpeg" length="1234"></link>
+  </entry>
+  <entry>
+    <id>urn:madek:media-entry:e2</id>
+    <title>Video</title>
+    <published>2016-05-26T09:00:00Z</published>
+    <updated>2016-05-26T09:00:00Z</updated>
+    <content type="video/mp4" src="https://example.com/p4"></content>
+    <link rel="alternate" href="https://example.com/p4" type="video/mp4"></link>
+    <link rel="enclosure" href="https://example.com/p4" type="video/mp4"></link>
+  </entry>
+</feed>
+`, buf.String())
+
+	generator.Limit = 1
+	buf.Reset()
+	err = generator.Write(&buf, coll, FeedRSS)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<enclosure url="https://example.com/f4" length="1234" type="audio/mpeg"></enclosure>`)
+}
+
+func TestFeedHandler(t *testing.T) {
+	handler := &FeedHandler{
+		Collection: func() (*Collection, error) {
+			return testFeedCollection(), nil
+		},
+		Generator: &FeedGenerator{},
+	}
+
+	for path, contentType := range map[string]string{
+		"/feed":             "application/atom+xml; charset=utf-8",
+		"/feed.rss":         "application/rss+xml; charset=utf-8",
+		"/feed?format=json": "application/feed+json; charset=utf-8",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, contentType, rec.Header().Get("Content-Type"), path)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/feed.xml", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -5,4 +5,6 @@ go 1.16
 require (
 	github.com/stretchr/testify v1.6.1
 	github.com/tidwall/gjson v1.6.5
+	golang.org/x/term v0.10.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,111 @@
+package madek
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func fakeAPI(t *testing.T, routes map[string]string) *httptest.Server {
+	server := httptest.NewServer(fakeHandler(routes))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func fakeHandler(routes map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// get route
+		key := r.URL.Path
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.Query().Encode()
+		}
+
+		// lookup body
+		body, ok := routes[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		// write body
+		w.Header().Set("Content-Type", "application/json-roa+json")
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+// fakeMadek returns routes that serve a collection "c1" with an image entry
+// "e1" and a video entry "e2" below the provided API root.
+func fakeMadek(root string) map[string]string {
+	rel := func(name, href string) string {
+		return fmt.Sprintf(`"%s": {"href": "%s%s"}`, name, root, href)
+	}
+
+	relations := func(list ...string) string {
+		return `"_json-roa": {"relations": {` + strings.Join(list, ",") + `}}`
+	}
+
+	preview := func(id, typ, contentType, size string, width, height int) string {
+		return fmt.Sprintf(`{"id": "%s", "media_type": "%s", "content_type": "%s", "thumbnail": "%s", "width": %d, "height": %d}`, id, typ, contentType, size, width, height)
+	}
+
+	return map[string]string{
+		root: `{` + relations(
+			rel("madek:api/collection", "sets/{id}"),
+			rel("madek:api/media-entry", "entries/{id}"),
+			rel("madek:api/media-entries", "entries/{?collection_id}"),
+			rel("madek:api/meta-datum", "data/{id}"),
+			rel("madek:api/preview", "previews/{id}"),
+			rel("madek:api/person", "people/{id}"),
+			rel("madek:api/keyword", "keywords/{id}"),
+		) + `}`,
+		root + "sets/c1": `{"id": "c1", "created_at": "2016-05-25T09:46:40Z", "updated_at": "2016-06-01T10:00:00Z", "meta_data_updated_at": "2016-06-01T10:00:00Z", "edit_session_updated_at": "2016-06-01T10:00:00Z", ` + relations(
+			rel("meta-data", "sets/c1/meta-data/"),
+		) + `}`,
+		root + "sets/c1/meta-data/": `{"meta-data": [
+			{"id": "m1", "meta_key_id": "madek_core:title"},
+			{"id": "m2", "meta_key_id": "madek_core:authors"},
+			{"id": "m3", "meta_key_id": "madek_core:keywords"},
+			{"id": "m0", "meta_key_id": "unsupported:key"}
+		]}`,
+		root + "entries/?collection_id=c1&page=0": `{"media-entries": [{"id": "e1"}, {"id": "e2"}], "_json-roa": {"collection": {}}}`,
+		root + "entries/e1": `{"id": "e1", "created_at": "2016-05-25T09:55:01Z", "updated_at": "2016-05-26T09:55:01Z", "meta_data_updated_at": "2016-05-26T09:55:01Z", "edit_session_updated_at": "2016-05-26T09:55:01Z", ` + relations(
+			rel("meta-data", "entries/e1/meta-data/"),
+			rel("media-file", "files/f1"),
+		) + `}`,
+		root + "entries/e1/meta-data/": `{"meta-data": [
+			{"id": "m4", "meta_key_id": "madek_core:title"},
+			{"id": "m5", "meta_key_id": "madek_core:copyright_notice"}
+		]}`,
+		root + "files/f1": `{"id": "f1", "filename": "image.jpg", "content_type": "image/jpeg", "size": 11, "previews": [{"id": "p1"}, {"id": "p2"}, {"id": "p3"}], ` + relations(
+			rel("data-stream", "files/f1/data-stream"),
+		) + `}`,
+		root + "previews/p1": preview("p1", "image", "image/jpeg", "small", 100, 56),
+		root + "previews/p2": preview("p2", "image", "image/jpeg", "large", 620, 348),
+		root + "previews/p3": preview("p3", "image", "image/jpeg", "large", 620, 348),
+		root + "entries/e2": `{"id": "e2", "created_at": "2016-05-25T10:49:49Z", "updated_at": "2016-05-27T10:49:49Z", "meta_data_updated_at": "2016-05-25T10:49:49Z", "edit_session_updated_at": "2016-05-25T10:49:49Z", ` + relations(
+			rel("meta-data", "entries/e2/meta-data/"),
+			rel("media-file", "files/f2"),
+		) + `}`,
+		root + "entries/e2/meta-data/": `{"meta-data": [
+			{"id": "m6", "meta_key_id": "madek_core:title"}
+		]}`,
+		root + "files/f2": `{"id": "f2", "filename": "video.mp4", "content_type": "video/mp4", "size": 22, "previews": [{"id": "p4"}, {"id": "p5"}, {"id": "p6"}, {"id": "p7"}], ` + relations(
+			rel("data-stream", "files/f2/data-stream"),
+		) + `}`,
+		root + "previews/p4": preview("p4", "image", "image/jpeg", "maximum", 1920, 1080),
+		root + "previews/p5": preview("p5", "video", "video/mp4", "large", 620, 348),
+		root + "previews/p6": preview("p6", "video", "video/webm", "large", 1920, 1080),
+		root + "previews/p7": preview("p7", "video", "video/mp4", "large", 1920, 1080),
+		root + "data/m1":     `{"type": "MetaDatum::Text", "value": "Collection"}`,
+		root + "data/m2":     `{"type": "MetaDatum::People", "value": [{"id": "a1"}]}`,
+		root + "data/m3":     `{"type": "MetaDatum::Keywords", "value": [{"id": "k1"}]}`,
+		root + "data/m4":     `{"type": "MetaDatum::Text", "value": "Image"}`,
+		root + "data/m5":     `{"type": "MetaDatum::Text", "value": "Holder"}`,
+		root + "data/m6":     `{"type": "MetaDatum::Text", "value": "Video"}`,
+		root + "people/a1":   `{"id": "a1", "first_name": "Jane", "last_name": "Doe"}`,
+		root + "keywords/k1": `{"id": "k1", "term": "Design"}`,
+	}
+}
@@ -0,0 +1,384 @@
+package madek
+
+import (
+	"regexp"
+	"strings"
+)
+
+// IIIFContext is the JSON-LD context of IIIF Presentation 3.0 documents.
+const IIIFContext = "http://iiif.io/api/presentation/3/context.json"
+
+// An IIIFLangMap maps languages to values.
+type IIIFLangMap map[string][]string
+
+// An IIIFLabelValue is a labeled value e.g. a meta data entry.
+type IIIFLabelValue struct {
+	Label IIIFLangMap `json:"label"`
+	Value IIIFLangMap `json:"value"`
+}
+
+// An IIIFResource is a content resource, a choice of resources or a link.
+type IIIFResource struct {
+	ID       string          `json:"id,omitempty"`
+	Type     string          `json:"type"`
+	Label    IIIFLangMap     `json:"label,omitempty"`
+	Format   string          `json:"format,omitempty"`
+	Width    int             `json:"width,omitempty"`
+	Height   int             `json:"height,omitempty"`
+	Duration float64         `json:"duration,omitempty"`
+	Items    []*IIIFResource `json:"items,omitempty"`
+}
+
+// An IIIFAnnotation paints a resource onto a canvas.
+type IIIFAnnotation struct {
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	Motivation string        `json:"motivation"`
+	Body       *IIIFResource `json:"body"`
+	Target     string        `json:"target"`
+}
+
+// An IIIFAnnotationPage holds the annotations of a canvas.
+type IIIFAnnotationPage struct {
+	ID    string            `json:"id"`
+	Type  string            `json:"type"`
+	Items []*IIIFAnnotation `json:"items"`
+}
+
+// An IIIFCanvas represents a single media entry.
+type IIIFCanvas struct {
+	ID        string                `json:"id"`
+	Type      string                `json:"type"`
+	Label     IIIFLangMap           `json:"label"`
+	Width     int                   `json:"width"`
+	Height    int                   `json:"height"`
+	Duration  float64               `json:"duration,omitempty"`
+	Metadata  []*IIIFLabelValue     `json:"metadata,omitempty"`
+	Homepage  []*IIIFResource       `json:"homepage,omitempty"`
+	Thumbnail []*IIIFResource       `json:"thumbnail,omitempty"`
+	Items     []*IIIFAnnotationPage `json:"items"`
+}
+
+// An IIIFManifest represents a collection as a IIIF Presentation 3.0
+// manifest.
+type IIIFManifest struct {
+	Context           string            `json:"@context"`
+	ID                string            `json:"id"`
+	Type              string            `json:"type"`
+	Label             IIIFLangMap       `json:"label"`
+	Summary           IIIFLangMap       `json:"summary,omitempty"`
+	Metadata          []*IIIFLabelValue `json:"metadata,omitempty"`
+	RequiredStatement *IIIFLabelValue   `json:"requiredStatement,omitempty"`
+	Rights            string            `json:"rights,omitempty"`
+	Homepage          []*IIIFResource   `json:"homepage,omitempty"`
+	Items             []*IIIFCanvas     `json:"items"`
+}
+
+// An IIIFExporter converts compiled collections into IIIF Presentation 3.0
+// manifests. Each media entry with video or image previews becomes a canvas
+// that is painted with its largest previews. Videos take precedence over
+// their image previews and are painted on time-based canvases.
+type IIIFExporter struct {
+	// The base URL the manifest is published at. The ids of the manifest,
+	// canvases and annotations are derived from it.
+	BaseURL string
+
+	// The address of the Madek instance used to link the web pages of the
+	// collection and its media entries. Links are omitted if empty.
+	Address string
+
+	// The language of the meta data values. Defaults to "none".
+	Language string
+
+	// The duration in seconds of videos whose previews do not provide one,
+	// as IIIF requires a duration for time-based canvases. Defaults to
+	// DefaultIIIFDuration.
+	VideoDuration float64
+}
+
+// DefaultIIIFDuration is the default duration in seconds of video canvases.
+const DefaultIIIFDuration = 1.0
+
+// Export will convert the provided collection into a IIIF manifest.
+func (e *IIIFExporter) Export(coll *Collection) *IIIFManifest {
+	// get base
+	base := strings.TrimSuffix(e.BaseURL, "/")
+
+	// prepare manifest
+	manifest := &IIIFManifest{
+		Context: IIIFContext,
+		ID:      base + "/manifest",
+		Type:    "Manifest",
+		Label:   e.lang(coll.ID),
+		Items:   []*IIIFCanvas{},
+	}
+
+	// add meta data
+	if md := coll.MetaData; md != nil {
+		if md.Title != "" {
+			manifest.Label = e.lang(md.Title)
+		}
+		if md.Description != "" {
+			manifest.Summary = e.lang(md.Description)
+		} else if md.Subtitle != "" {
+			manifest.Summary = e.lang(md.Subtitle)
+		}
+		manifest.Metadata = e.metadata(md)
+		manifest.RequiredStatement = e.requiredStatement(md)
+		manifest.Rights = rightsURI(md.Copyright.Licenses)
+	}
+
+	// add homepage
+	manifest.Homepage = e.homepage(CollectionKind, coll.ID, manifest.Label)
+
+	// add canvases
+	for _, entry := range coll.MediaEntries {
+		if canvas := e.canvas(base, entry); canvas != nil {
+			manifest.Items = append(manifest.Items, canvas)
+		}
+	}
+
+	return manifest
+}
+
+func (e *IIIFExporter) canvas(base string, entry *MediaEntry) *IIIFCanvas {
+	// get renditions
+	set := RenditionSet(nil)
+	if previews := entry.PublicPreviews(); len(previews) > 0 {
+		set = (&MediaEntry{Previews: previews}).Renditions()
+	}
+
+	// select painted renditions, videos take precedence over images
+	groups := set.Videos()
+	if len(groups) == 0 {
+		groups = set.Images()
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// collect bodies
+	var bodies []*IIIFResource
+	var width, height int
+	var duration float64
+	for _, group := range groups {
+		preview := group.Largest()
+		body := &IIIFResource{
+			ID:     preview.URL,
+			Type:   iiifType(preview.Type),
+			Format: preview.ContentType,
+			Width:  preview.Width,
+			Height: preview.Height,
+		}
+		if preview.Type == "video" {
+			body.Duration = preview.Duration
+			if body.Duration <= 0 {
+				body.Duration = e.videoDuration()
+			}
+		}
+		bodies = append(bodies, body)
+		if preview.Width*preview.Height > width*height {
+			width, height = preview.Width, preview.Height
+		}
+		if body.Duration > duration {
+			duration = body.Duration
+		}
+	}
+
+	// offer a choice if there are multiple bodies
+	body := bodies[0]
+	if len(bodies) > 1 {
+		body = &IIIFResource{
+			Type:  "Choice",
+			Items: bodies,
+		}
+	}
+
+	// prepare canvas
+	id := base + "/canvas/" + entry.ID
+	canvas := &IIIFCanvas{
+		ID:       id,
+		Type:     "Canvas",
+		Label:    e.lang(entry.ID),
+		Width:    width,
+		Height:   height,
+		Duration: duration,
+		Items: []*IIIFAnnotationPage{{
+			ID:   id + "/page",
+			Type: "AnnotationPage",
+			Items: []*IIIFAnnotation{{
+				ID:         id + "/annotation",
+				Type:       "Annotation",
+				Motivation: "painting",
+				Body:       body,
+				Target:     id,
+			}},
+		}},
+	}
+
+	// add meta data
+	if md := entry.MetaData; md != nil {
+		if md.Title != "" {
+			canvas.Label = e.lang(md.Title)
+		}
+		canvas.Metadata = e.metadata(md)
+	}
+
+	// add homepage
+	canvas.Homepage = e.homepage(MediaEntryKind, entry.ID, canvas.Label)
+
+	// add thumbnail
+	if group := set.Images(); len(group) > 0 {
+		thumb := (&MediaEntry{Previews: group[0].Previews}).SelectPreview(PreviewFilter{Width: 300})
+		canvas.Thumbnail = []*IIIFResource{{
+			ID:     thumb.URL,
+			Type:   "Image",
+			Format: thumb.ContentType,
+			Width:  thumb.Width,
+			Height: thumb.Height,
+		}}
+	}
+
+	return canvas
+}
+
+func (e *IIIFExporter) metadata(md *MetaData) []*IIIFLabelValue {
+	// collect values
+	var list []*IIIFLabelValue
+	for _, item := range []struct {
+		label  string
+		values []string
+	}{
+		{label: "Subtitle", values: []string{md.Subtitle}},
+		{label: "Authors", values: authorNames(md.Authors)},
+		{label: "Year", values: []string{md.Year}},
+		{label: "Keywords", values: md.Keywords},
+		{label: "Genres", values: md.Genres},
+		{label: "Affiliation", values: groupNames(md.Affiliation)},
+		{label: "License", values: md.Copyright.Licenses},
+	} {
+		// remove empty values
+		var values []string
+		for _, value := range item.values {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		// add entry
+		list = append(list, &IIIFLabelValue{
+			Label: IIIFLangMap{"en": {item.label}},
+			Value: IIIFLangMap{e.language(): values},
+		})
+	}
+
+	return list
+}
+
+func (e *IIIFExporter) requiredStatement(md *MetaData) *IIIFLabelValue {
+	// collect values
+	var values []string
+	for _, value := range []string{md.Copyright.Holder, md.Copyright.Usage} {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	return &IIIFLabelValue{
+		Label: IIIFLangMap{"en": {"Attribution"}},
+		Value: IIIFLangMap{e.language(): values},
+	}
+}
+
+func (e *IIIFExporter) homepage(kind Kind, id string, label IIIFLangMap) []*IIIFResource {
+	// check address
+	if e.Address == "" {
+		return nil
+	}
+
+	return []*IIIFResource{{
+		ID:     WebURL(e.Address, kind, id),
+		Type:   "Text",
+		Label:  label,
+		Format: "text/html",
+	}}
+}
+
+func (e *IIIFExporter) lang(value string) IIIFLangMap {
+	return IIIFLangMap{e.language(): {value}}
+}
+
+func (e *IIIFExporter) language() string {
+	// check language
+	if e.Language == "" {
+		return "none"
+	}
+
+	return e.Language
+}
+
+func (e *IIIFExporter) videoDuration() float64 {
+	// check duration
+	if e.VideoDuration <= 0 {
+		return DefaultIIIFDuration
+	}
+
+	return e.VideoDuration
+}
+
+var ccLicense = regexp.MustCompile(`(?i)\bcc[ -]?(by(?:[ -](?:nc|sa|nd))*)(?:[ -](\d\.\d))?(?:[ -]([a-z]{2}))?\b`)
+
+func rightsURI(licenses []string) string {
+	for _, license := range licenses {
+		// check urls
+		if strings.HasPrefix(license, "http://") || strings.HasPrefix(license, "https://") {
+			return license
+		}
+
+		// check public domain
+		lower := strings.ToLower(license)
+		if strings.Contains(lower, "cc0") {
+			return "http://creativecommons.org/publicdomain/zero/1.0/"
+		} else if strings.Contains(lower, "public domain") {
+			return "http://creativecommons.org/publicdomain/mark/1.0/"
+		}
+
+		// check creative commons
+		match := ccLicense.FindStringSubmatch(lower)
+		if match == nil {
+			continue
+		}
+
+		// the version is required and 4.0 licenses are not ported
+		code, version, port := strings.Replace(match[1], " ", "-", -1), match[2], match[3]
+		if version == "" || version == "4.0" && port != "" {
+			continue
+		}
+
+		// add port
+		if port != "" {
+			version += "/" + port
+		}
+
+		return "http://creativecommons.org/licenses/" + code + "/" + version + "/"
+	}
+
+	return ""
+}
+
+func iiifType(typ string) string {
+	switch typ {
+	case "video":
+		return "Video"
+	case "audio":
+		return "Sound"
+	default:
+		return "Image"
+	}
+}
@@ -0,0 +1,164 @@
+package madek
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIIIFExporter(t *testing.T) {
+	video := &MediaEntry{ID: "e2", MetaData: &MetaData{Title: "Video"}}
+	for _, preview := range videoEntry.Previews {
+		p := *preview
+		p.URL = "https://madek.example.com/media/" + p.ID
+		video.Previews = append(video.Previews, &p)
+	}
+
+	coll := &Collection{
+		ID: "c1",
+		MetaData: &MetaData{
+			Title:       "Collection",
+			Description: "Description",
+			Authors:     []*Author{{ID: "a1", FirstName: "Jane", LastName: "Doe"}},
+			Keywords:    []string{"Design"},
+			Copyright: Copyright{
+				Holder:   "Holder",
+				Licenses: []string{"CC-By-SA-CH: Attribution Share Alike"},
+			},
+		},
+		MediaEntries: []*MediaEntry{
+			{
+				ID:       "e1",
+				MetaData: &MetaData{Title: "Image", Year: "2016"},
+				Previews: []*Preview{
+					{ID: "p1", Type: "image", ContentType: "image/jpeg", Width: 100, Height: 56, URL: "https://madek.example.com/media/p1"},
+					{ID: "p2", Type: "image", ContentType: "image/jpeg", Width: 620, Height: 348, URL: "https://madek.example.com/media/p2"},
+				},
+			},
+			video,
+			{ID: "e3", MetaData: &MetaData{Title: "Document"}},
+			{ID: "e4", Previews: video.Previews, Permissions: &Permissions{}},
+		},
+	}
+
+	exporter := &IIIFExporter{
+		BaseURL: "https://example.com/iiif/c1/",
+		Address: "https://madek.example.com",
+	}
+
+	manifest := exporter.Export(coll)
+	assert.Equal(t, IIIFContext, manifest.Context)
+	assert.Equal(t, "https://example.com/iiif/c1/manifest", manifest.ID)
+	assert.Equal(t, IIIFLangMap{"none": {"Collection"}}, manifest.Label)
+	assert.Equal(t, IIIFLangMap{"none": {"Description"}}, manifest.Summary)
+	assert.Equal(t, &IIIFLabelValue{
+		Label: IIIFLangMap{"en": {"Attribution"}},
+		Value: IIIFLangMap{"none": {"Holder"}},
+	}, manifest.RequiredStatement)
+	assert.Equal(t, "", manifest.Rights)
+	assert.Equal(t, []*IIIFLabelValue{
+		{Label: IIIFLangMap{"en": {"Authors"}}, Value: IIIFLangMap{"none": {"Jane Doe"}}},
+		{Label: IIIFLangMap{"en": {"Keywords"}}, Value: IIIFLangMap{"none": {"Design"}}},
+		{Label: IIIFLangMap{"en": {"License"}}, Value: IIIFLangMap{"none": {"CC-By-SA-CH: Attribution Share Alike"}}},
+	}, manifest.Metadata)
+	assert.Equal(t, "https://madek.example.com/sets/c1", manifest.Homepage[0].ID)
+	assert.Len(t, manifest.Items, 2)
+
+	image := manifest.Items[0]
+	assert.Equal(t, "https://example.com/iiif/c1/canvas/e1", image.ID)
+	assert.Equal(t, IIIFLangMap{"none": {"Image"}}, image.Label)
+	assert.Equal(t, 620, image.Width)
+	assert.Equal(t, 348, image.Height)
+	assert.Equal(t, "https://madek.example.com/entries/e1", image.Homepage[0].ID)
+	assert.Equal(t, "https://madek.example.com/media/p2", image.Thumbnail[0].ID)
+	assert.Equal(t, &IIIFAnnotation{
+		ID:         "https://example.com/iiif/c1/canvas/e1/annotation",
+		Type:       "Annotation",
+		Motivation: "painting",
+		Body: &IIIFResource{
+			ID:     "https://madek.example.com/media/p2",
+			Type:   "Image",
+			Format: "image/jpeg",
+			Width:  620,
+			Height: 348,
+		},
+		Target: "https://example.com/iiif/c1/canvas/e1",
+	}, image.Items[0].Items[0])
+
+	canvas := manifest.Items[1]
+	assert.Equal(t, 1920, canvas.Width)
+	assert.Equal(t, 1080, canvas.Height)
+	assert.Equal(t, DefaultIIIFDuration, canvas.Duration)
+	assert.Equal(t, "https://madek.example.com/media/cb2705a3", canvas.Thumbnail[0].ID)
+	assert.Equal(t, &IIIFResource{
+		Type: "Choice",
+		Items: []*IIIFResource{
+			{
+				ID:       "https://madek.example.com/media/62911c6b",
+				Type:     "Video",
+				Format:   "video/mp4",
+				Width:    1920,
+				Height:   1080,
+				Duration: DefaultIIIFDuration,
+			},
+			{
+				ID:       "https://madek.example.com/media/80e8b5b2",
+				Type:     "Video",
+				Format:   "video/webm",
+				Width:    1920,
+				Height:   1080,
+				Duration: DefaultIIIFDuration,
+			},
+		},
+	}, canvas.Items[0].Items[0].Body)
+
+	data, err := json.Marshal(manifest)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"@context":"http://iiif.io/api/presentation/3/context.json"`)
+	assert.Contains(t, string(data), `"requiredStatement":{`)
+	assert.Contains(t, string(data), `"duration":1`)
+
+	onlyVideo := &MediaEntry{ID: "e5"}
+	for _, preview := range video.Previews {
+		if preview.Type == "video" && preview.ContentType == "video/mp4" {
+			p := *preview
+			p.Duration = 12.5
+			onlyVideo.Previews = append(onlyVideo.Previews, &p)
+		}
+	}
+	exporter.VideoDuration = 60
+	manifest = exporter.Export(&Collection{ID: "c2", MediaEntries: []*MediaEntry{onlyVideo}})
+	assert.Len(t, manifest.Items, 1)
+	assert.Equal(t, 12.5, manifest.Items[0].Duration)
+	assert.Empty(t, manifest.Items[0].Thumbnail)
+	assert.Equal(t, &IIIFResource{
+		ID:       "https://madek.example.com/media/62911c6b",
+		Type:     "Video",
+		Format:   "video/mp4",
+		Width:    1920,
+		Height:   1080,
+		Duration: 12.5,
+	}, manifest.Items[0].Items[0].Items[0].Body)
+
+	onlyVideo.Previews[0].Duration = 0
+	onlyVideo.Previews[1].Duration = 0
+	manifest = exporter.Export(&Collection{ID: "c2", MediaEntries: []*MediaEntry{onlyVideo}})
+	assert.Equal(t, 60.0, manifest.Items[0].Duration)
+}
+
+func TestRightsURI(t *testing.T) {
+	assert.Equal(t, "", rightsURI(nil))
+	assert.Equal(t, "", rightsURI([]string{"All rights reserved"}))
+	assert.Equal(t, "", rightsURI([]string{"CC BY"}))
+	assert.Equal(t, "", rightsURI([]string{"CC-By-SA-CH: Attribution Share Alike"}))
+	assert.Equal(t, "", rightsURI([]string{"CC BY 4.0 CH"}))
+	assert.Equal(t, "http://creativecommons.org/licenses/by/4.0/", rightsURI([]string{"CC BY 4.0"}))
+	assert.Equal(t, "http://creativecommons.org/licenses/by/4.0/", rightsURI([]string{"CC BY 4.0 International"}))
+	assert.Equal(t, "http://creativecommons.org/licenses/by-nc-nd/2.5/", rightsURI([]string{"cc-by-nc-nd-2.5"}))
+	assert.Equal(t, "http://creativecommons.org/licenses/by-sa/3.0/ch/", rightsURI([]string{"CC BY-SA 3.0 CH"}))
+	assert.Equal(t, "http://creativecommons.org/licenses/by-sa/3.0/ch/", rightsURI([]string{"Unknown", "CC-By-SA 3.0 CH: Attribution Share Alike"}))
+	assert.Equal(t, "http://creativecommons.org/publicdomain/zero/1.0/", rightsURI([]string{"CC0 1.0"}))
+	assert.Equal(t, "http://creativecommons.org/publicdomain/mark/1.0/", rightsURI([]string{"Public Domain"}))
+	assert.Equal(t, "https://example.com/license", rightsURI([]string{"https://example.com/license"}))
+}
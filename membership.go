@@ -0,0 +1,118 @@
+package madek
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+)
+
+// CreateCollection will create a new collection with the provided title and
+// return it compiled. If setting the title fails, the created collection is
+// returned together with the error.
+func (c *Client) CreateCollection(title string) (*Collection, error) {
+	// get url
+	url, err := c.Expand("collections", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// create collection
+	collStr, err := c.SendJSON("POST", url, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	// get id
+	id := gjson.Get(collStr, "id").Str
+	if id == "" {
+		return nil, fmt.Errorf("missing id of created collection")
+	}
+
+	// set title
+	if title != "" {
+		err = c.SetTitle(CollectionKind, id, title)
+		if err != nil {
+			coll, cerr := c.CompileCollection(id)
+			if cerr != nil {
+				coll = &Collection{ID: id}
+			}
+			return coll, err
+		}
+	}
+
+	return c.CompileCollection(id)
+}
+
+// AddToCollection will add the specified media entry to the specified
+// collection.
+func (c *Client) AddToCollection(collectionID, entryID string) error {
+	// get url
+	url, err := c.Expand("collection-media-entry-arcs", nil)
+	if err != nil {
+		return err
+	}
+
+	// create arc
+	_, err = c.SendJSON("POST", url, map[string]interface{}{
+		"collection_id":  collectionID,
+		"media_entry_id": entryID,
+	})
+
+	return err
+}
+
+// RemoveFromCollection will remove the specified media entry from the
+// specified collection.
+func (c *Client) RemoveFromCollection(collectionID, entryID string) error {
+	// get arc url
+	url, err := c.arcURL(collectionID, entryID)
+	if err != nil {
+		return err
+	}
+
+	// delete arc
+	_, err = c.Send("DELETE", url, "", nil)
+
+	return err
+}
+
+// SetCover will set the specified media entry as the cover of the specified
+// collection. The media entry must be part of the collection.
+func (c *Client) SetCover(collectionID, entryID string) error {
+	// get arc url
+	url, err := c.arcURL(collectionID, entryID)
+	if err != nil {
+		return err
+	}
+
+	// update arc
+	_, err = c.SendJSON("PATCH", url, map[string]interface{}{
+		"cover": true,
+	})
+
+	return err
+}
+
+func (c *Client) arcURL(collectionID, entryID string) (string, error) {
+	// get search url
+	url, err := c.Expand("collection-media-entry-arcs", map[string]string{
+		"collection_id":  collectionID,
+		"media_entry_id": entryID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// find arc
+	pager := c.Paginate(url, "collection-media-entry-arcs", 0)
+	if !pager.Next() {
+		if pager.Error() != nil {
+			return "", pager.Error()
+		}
+		return "", ErrNotFound
+	}
+
+	return c.Expand("collection-media-entry-arc", map[string]string{
+		"id": pager.Item().Get("id").Str,
+	})
+}
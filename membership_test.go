@@ -0,0 +1,101 @@
+package madek
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionMembership(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/collection-media-entry-arcs/?collection_id=c1&media_entry_id=e2&page=0"] = `{"collection-media-entry-arcs": [{"id": "arc2"}]}`
+	routes["/api/collection-media-entry-arcs/?collection_id=c1&media_entry_id=e3&page=0"] = `{"collection-media-entry-arcs": []}`
+
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fakeHandler(routes).ServeHTTP(w, r)
+			return
+		}
+
+		data, _ := ioutil.ReadAll(r.Body)
+		writes = append(writes, r.Method+" "+r.URL.Path+" "+string(data))
+		if strings.Contains(string(data), "Invalid") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "c1"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", "")
+
+	coll, err := client.CreateCollection("Exhibition")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", coll.ID)
+
+	assert.NoError(t, client.AddToCollection("c1", "e3"))
+	assert.NoError(t, client.SetCover("c1", "e2"))
+	assert.NoError(t, client.RemoveFromCollection("c1", "e2"))
+	assert.Equal(t, ErrNotFound, client.RemoveFromCollection("c1", "e3"))
+
+	assert.Equal(t, []string{
+		`POST /api/collections/ {}`,
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Exhibition"}`,
+		`POST /api/collection-media-entry-arcs/ {"collection_id":"c1","media_entry_id":"e3"}`,
+		`PATCH /api/collection-media-entry-arcs/arc2 {"cover":true}`,
+		`DELETE /api/collection-media-entry-arcs/arc2 `,
+	}, writes)
+
+	writes = nil
+	coll, err = client.CreateCollection("Invalid")
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	assert.Equal(t, "c1", coll.ID)
+	assert.Equal(t, []string{
+		`POST /api/collections/ {}`,
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Invalid"}`,
+	}, writes)
+}
+
+func TestRefreshCollection(t *testing.T) {
+	routes := fakeMadek("/api/")
+	server := fakeAPI(t, routes)
+	client := NewClient(server.URL, "", "")
+
+	coll, err := client.CompileCollection("c1")
+	assert.NoError(t, err)
+	assert.Len(t, coll.MediaEntries, 2)
+	image := coll.MediaEntries[0]
+
+	routes["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}, {"id": "e3"}]}`
+	routes["/api/entries/e3"] = routes["/api/entries/e2"]
+	routes["/api/data/m6"] = `{"type": "MetaDatum::Text", "value": "Other Video"}`
+
+	err = client.RefreshCollection(coll)
+	assert.NoError(t, err)
+	assert.Len(t, coll.MediaEntries, 2)
+	assert.True(t, image == coll.MediaEntries[0])
+	assert.Equal(t, "e3", coll.MediaEntries[1].ID)
+	assert.Equal(t, "Other Video", coll.MediaEntries[1].MetaData.Title)
+	assert.Nil(t, coll.Permissions)
+
+	for _, path := range []string{"collections/c1", "media-entries/e1", "media-entries/e3"} {
+		routes["/api/"+path+"/perms/resource"] = `{"get_metadata_and_previews": true}`
+		routes["/api/"+path+"/perms/users/?page=0"] = `{}`
+		routes["/api/"+path+"/perms/groups/?page=0"] = `{}`
+		routes["/api/"+path+"/perms/api-clients/?page=0"] = `{}`
+	}
+
+	client.IncludePermissions = true
+	err = client.RefreshCollection(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, &Permissions{Public: true}, coll.Permissions)
+	assert.Equal(t, &Permissions{Public: true}, coll.MediaEntries[0].Permissions)
+	assert.Equal(t, &Permissions{Public: true}, coll.MediaEntries[1].Permissions)
+	assert.Nil(t, image.Permissions)
+}
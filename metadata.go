@@ -0,0 +1,388 @@
+package madek
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// SetText will set the text meta datum with the specified key of the
+// specified resource. An empty value deletes the meta datum.
+func (c *Client) SetText(kind Kind, id, metaKey, value string) error {
+	// delete empty values
+	if value == "" {
+		return c.deleteMetaDatum(kind, id, metaKey)
+	}
+
+	return c.putMetaDatum(kind, id, metaKey, value)
+}
+
+// SetTitle will set the title of the specified resource.
+func (c *Client) SetTitle(kind Kind, id, title string) error {
+	return c.SetText(kind, id, "madek_core:title", title)
+}
+
+// SetSubtitle will set the subtitle of the specified resource.
+func (c *Client) SetSubtitle(kind Kind, id, subtitle string) error {
+	return c.SetText(kind, id, "madek_core:subtitle", subtitle)
+}
+
+// SetDescription will set the description of the specified resource.
+func (c *Client) SetDescription(kind Kind, id, description string) error {
+	return c.SetText(kind, id, "madek_core:description", description)
+}
+
+// SetYear will set the portrayed object date of the specified resource.
+func (c *Client) SetYear(kind Kind, id, year string) error {
+	return c.SetText(kind, id, "madek_core:portrayed_object_date", year)
+}
+
+// SetCopyright will set the copyright holder, usage and licenses of the
+// specified resource. Empty holders, usages and licenses delete the respective
+// meta datum. Licenses are resolved as terms of the "copyright:license"
+// keywords and unknown terms are created if the vocabulary allows it.
+func (c *Client) SetCopyright(kind Kind, id string, copyright Copyright) error {
+	// set holder
+	err := c.SetText(kind, id, "madek_core:copyright_notice", copyright.Holder)
+	if err != nil {
+		return err
+	}
+
+	// set usage
+	err = c.SetText(kind, id, "copyright:copyright_usage", copyright.Usage)
+	if err != nil {
+		return err
+	}
+
+	return c.SetKeywords(kind, id, "copyright:license", copyright.Licenses)
+}
+
+// SetKeywords will set the keywords meta datum with the specified key to the
+// provided terms. Unknown terms are created if the vocabulary allows it. An
+// empty list of terms deletes the meta datum.
+func (c *Client) SetKeywords(kind Kind, id, metaKey string, terms []string) error {
+	// resolve terms
+	ids := make([]string, 0, len(terms))
+	for _, term := range terms {
+		keywordID, err := c.ResolveKeyword(metaKey, term)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, keywordID)
+	}
+
+	return c.putMetaDatumIDs(kind, id, metaKey, ids)
+}
+
+// AddKeywords will add the provided terms to the keywords of the specified
+// resource. Unknown terms are created if the vocabulary allows it.
+func (c *Client) AddKeywords(kind Kind, id string, terms ...string) error {
+	// get current keywords
+	ids, err := c.metaDatumIDs(kind, id, "madek_core:keywords")
+	if err != nil {
+		return err
+	}
+
+	// resolve and add terms
+	for _, term := range terms {
+		keywordID, err := c.ResolveKeyword("madek_core:keywords", term)
+		if err != nil {
+			return err
+		}
+		if !stringInList(ids, keywordID) {
+			ids = append(ids, keywordID)
+		}
+	}
+
+	return c.putMetaDatum(kind, id, "madek_core:keywords", ids)
+}
+
+// RemoveKeywords will remove the provided terms from the keywords of the
+// specified resource. The meta datum is deleted if no keywords remain.
+func (c *Client) RemoveKeywords(kind Kind, id string, terms ...string) error {
+	// get current keywords
+	ids, err := c.metaDatumIDs(kind, id, "madek_core:keywords")
+	if err != nil {
+		return err
+	}
+
+	// keep keywords with other terms
+	keep := make([]string, 0, len(ids))
+	for _, keywordID := range ids {
+		term, err := c.GetKeywordTerm(keywordID)
+		if err != nil {
+			return err
+		}
+		if !stringInList(terms, term) {
+			keep = append(keep, keywordID)
+		}
+	}
+
+	return c.putMetaDatumIDs(kind, id, "madek_core:keywords", keep)
+}
+
+// AddAuthors will add the provided authors to the specified resource.
+// Authors without an id are resolved by name and created if missing.
+func (c *Client) AddAuthors(kind Kind, id string, authors ...*Author) error {
+	// get current authors
+	ids, err := c.metaDatumIDs(kind, id, "madek_core:authors")
+	if err != nil {
+		return err
+	}
+
+	// resolve and add authors
+	for _, author := range authors {
+		personID, err := c.ResolvePerson(author)
+		if err != nil {
+			return err
+		}
+		if !stringInList(ids, personID) {
+			ids = append(ids, personID)
+		}
+	}
+
+	return c.putMetaDatum(kind, id, "madek_core:authors", ids)
+}
+
+// RemoveAuthors will remove the provided authors from the specified
+// resource. Authors are matched by id or, if missing, by name. The meta datum
+// is deleted if no authors remain.
+func (c *Client) RemoveAuthors(kind Kind, id string, authors ...*Author) error {
+	// get current authors
+	ids, err := c.metaDatumIDs(kind, id, "madek_core:authors")
+	if err != nil {
+		return err
+	}
+
+	// keep other authors
+	keep := make([]string, 0, len(ids))
+	for _, personID := range ids {
+		current, err := c.GetAuthor(personID)
+		if err != nil {
+			return err
+		}
+		if !authorInList(authors, current) {
+			keep = append(keep, personID)
+		}
+	}
+
+	return c.putMetaDatumIDs(kind, id, "madek_core:authors", keep)
+}
+
+// ResolveKeyword will return the id of the keyword with the provided term in
+// the vocabulary of the specified meta key. If the keyword does not exist and
+// the meta key allows extending its list, the keyword is created.
+func (c *Client) ResolveKeyword(metaKey, term string) (string, error) {
+	// get search url
+	url, err := c.Expand("keywords", map[string]string{
+		"meta_key_id": metaKey,
+		"term":        term,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// search keywords
+	pager := c.Paginate(url, "keywords", 0)
+	for pager.Next() {
+		if strings.EqualFold(pager.Item().Get("term").Str, term) {
+			return c.cacheKeyword(pager.Item().Get("id").Str, pager.Item().Get("term").Str), nil
+		}
+	}
+	if pager.Error() != nil {
+		return "", pager.Error()
+	}
+
+	// fetch meta key
+	metaKeyStr, err := c.fetchByID("meta-key", metaKey)
+	if err != nil {
+		return "", err
+	}
+
+	// check if extensible
+	if !gjson.Get(metaKeyStr, "is_extensible_list").Bool() && !gjson.Get(metaKeyStr, "is_extensible").Bool() {
+		return "", fmt.Errorf("%w: unknown term %q for %s", ErrValidationFailed, term, metaKey)
+	}
+
+	// get create url
+	url, err = c.Expand("keywords", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// create keyword
+	keywordStr, err := c.SendJSON("POST", url, map[string]string{
+		"meta_key_id": metaKey,
+		"term":        term,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return c.cacheKeyword(gjson.Get(keywordStr, "id").Str, term), nil
+}
+
+// ResolvePerson will return the id of the person that matches the provided
+// author. Authors with an id are verified, others are searched by name and
+// created if missing.
+func (c *Client) ResolvePerson(author *Author) (string, error) {
+	// verify id
+	if author.ID != "" {
+		_, err := c.GetAuthor(author.ID)
+		if err != nil {
+			return "", err
+		}
+		return author.ID, nil
+	}
+
+	// get search url
+	url, err := c.Expand("people", map[string]string{
+		"search": author.Name(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// search people
+	pager := c.Paginate(url, "people", 0)
+	for pager.Next() {
+		item := pager.Item()
+		if item.Get("first_name").Str == author.FirstName && item.Get("last_name").Str == author.LastName {
+			return item.Get("id").Str, nil
+		}
+	}
+	if pager.Error() != nil {
+		return "", pager.Error()
+	}
+
+	// get create url
+	url, err = c.Expand("people", nil)
+	if err != nil {
+		return "", err
+	}
+
+	// create person
+	personStr, err := c.SendJSON("POST", url, map[string]string{
+		"subtype":    "Person",
+		"first_name": author.FirstName,
+		"last_name":  author.LastName,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return gjson.Get(personStr, "id").Str, nil
+}
+
+func (c *Client) metaDatumIDs(kind Kind, id, metaKey string) ([]string, error) {
+	// fetch resource
+	resourceStr, err := c.fetchByID(string(kind), id)
+	if err != nil {
+		return nil, err
+	}
+
+	// get meta data url
+	metaDataURL, err := c.followOrExpand(resourceStr, "meta-data", string(kind)+"-meta-data", map[string]string{"id": id})
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch meta data
+	metaDataStr, err := c.Fetch(metaDataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// find meta datum
+	var ids []string
+	for _, metaDatum := range gjson.Get(metaDataStr, "meta-data").Array() {
+		// check key
+		if metaDatum.Get("meta_key_id").Str != metaKey {
+			continue
+		}
+
+		// fetch meta datum
+		metaDatumStr, err := c.fetchByID("meta-datum", metaDatum.Get("id").Str)
+		if err != nil {
+			return nil, err
+		}
+
+		// collect ids
+		for _, item := range gjson.Get(metaDatumStr, "value.#.id").Array() {
+			ids = append(ids, item.Str)
+		}
+	}
+
+	return ids, nil
+}
+
+func (c *Client) putMetaDatum(kind Kind, id, metaKey string, value interface{}) error {
+	// get url
+	url, err := c.Expand(string(kind)+"-meta-datum", map[string]string{
+		"id":          id,
+		"meta_key_id": metaKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	// write meta datum
+	_, err = c.SendJSON("PUT", url, map[string]interface{}{
+		"value": value,
+	})
+
+	return err
+}
+
+func (c *Client) putMetaDatumIDs(kind Kind, id, metaKey string, ids []string) error {
+	// delete empty lists
+	if len(ids) == 0 {
+		return c.deleteMetaDatum(kind, id, metaKey)
+	}
+
+	return c.putMetaDatum(kind, id, metaKey, ids)
+}
+
+func (c *Client) deleteMetaDatum(kind Kind, id, metaKey string) error {
+	// get url
+	url, err := c.Expand(string(kind)+"-meta-datum", map[string]string{
+		"id":          id,
+		"meta_key_id": metaKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	// delete meta datum
+	_, err = c.Send("DELETE", url, "", nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (c *Client) cacheKeyword(id, term string) string {
+	// acquire mutex
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// cache term
+	c.keywordCache[id] = term
+
+	return id
+}
+
+func authorInList(list []*Author, author *Author) bool {
+	for _, item := range list {
+		if item.ID != "" && item.ID == author.ID {
+			return true
+		} else if item.ID == "" && item.FirstName == author.FirstName && item.LastName == author.LastName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package madek
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetaDataWrites(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/keywords/?meta_key_id=madek_core%3Akeywords&page=0&term=Design"] = `{"keywords": [{"id": "k1", "term": "Design"}]}`
+	routes["/api/keywords/?meta_key_id=madek_core%3Akeywords&page=0&term=Light"] = `{"keywords": []}`
+	routes["/api/keywords/?meta_key_id=copyright%3Alicense&page=0&term=Unknown"] = `{"keywords": []}`
+	routes["/api/meta-keys/madek_core:keywords"] = `{"id": "madek_core:keywords", "is_extensible_list": true}`
+	routes["/api/meta-keys/copyright:license"] = `{"id": "copyright:license", "is_extensible_list": false}`
+	routes["/api/people/?page=0&search=John+Doe"] = `{"people": [{"id": "a9", "first_name": "John", "last_name": "Doe"}]}`
+	routes["/api/people/?page=0&search=Max+Muster"] = `{"people": []}`
+
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fakeHandler(routes).ServeHTTP(w, r)
+			return
+		}
+
+		data, _ := ioutil.ReadAll(r.Body)
+		writes = append(writes, r.Method+" "+r.URL.Path+" "+string(data))
+
+		switch r.URL.Path {
+		case "/api/keywords/":
+			_, _ = w.Write([]byte(`{"id": "k2"}`))
+		case "/api/people/":
+			_, _ = w.Write([]byte(`{"id": "a2"}`))
+		case "/api/media-entries/e1/meta-data/copyright:copyright_usage":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", "")
+
+	err := client.SetTitle(MediaEntryKind, "e1", "New Title")
+	assert.NoError(t, err)
+
+	err = client.AddKeywords(CollectionKind, "c1", "Design", "Light")
+	assert.NoError(t, err)
+
+	err = client.RemoveKeywords(CollectionKind, "c1", "Design")
+	assert.NoError(t, err)
+
+	err = client.AddAuthors(CollectionKind, "c1", &Author{FirstName: "John", LastName: "Doe"}, &Author{FirstName: "Max", LastName: "Muster"})
+	assert.NoError(t, err)
+
+	err = client.RemoveAuthors(CollectionKind, "c1", &Author{FirstName: "Jane", LastName: "Doe"})
+	assert.NoError(t, err)
+
+	err = client.SetSubtitle(MediaEntryKind, "e1", "")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`PUT /api/media-entries/e1/meta-data/madek_core:title {"value":"New Title"}`,
+		`POST /api/keywords/ {"meta_key_id":"madek_core:keywords","term":"Light"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:keywords {"value":["k1","k2"]}`,
+		`DELETE /api/collections/c1/meta-data/madek_core:keywords `,
+		`POST /api/people/ {"first_name":"Max","last_name":"Muster","subtype":"Person"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:authors {"value":["a1","a9","a2"]}`,
+		`DELETE /api/collections/c1/meta-data/madek_core:authors `,
+		`DELETE /api/media-entries/e1/meta-data/madek_core:subtitle `,
+	}, writes)
+
+	writes = nil
+	err = client.SetCopyright(MediaEntryKind, "e1", Copyright{Holder: "ZHdK", Licenses: []string{"Unknown"}})
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	assert.Equal(t, []string{
+		`PUT /api/media-entries/e1/meta-data/madek_core:copyright_notice {"value":"ZHdK"}`,
+		`DELETE /api/media-entries/e1/meta-data/copyright:copyright_usage `,
+	}, writes)
+
+	writes = nil
+	err = client.SetCopyright(MediaEntryKind, "e1", Copyright{Holder: "ZHdK"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`PUT /api/media-entries/e1/meta-data/madek_core:copyright_notice {"value":"ZHdK"}`,
+		`DELETE /api/media-entries/e1/meta-data/copyright:copyright_usage `,
+		`DELETE /api/media-entries/e1/meta-data/copyright:license `,
+	}, writes)
+}
@@ -0,0 +1,448 @@
+package madek
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// A MigrationReport maps the migrated resources of the source instance to the
+// resources of the target instance.
+type MigrationReport struct {
+	SourceCollection string            `json:"source_collection"`
+	TargetCollection string            `json:"target_collection"`
+	MediaEntries     map[string]string `json:"media_entries"`
+	People           map[string]string `json:"people"`
+	Groups           map[string]string `json:"groups"`
+	Keywords         map[string]string `json:"keywords"`
+	Checksums        map[string]string `json:"checksums"`
+	Unfinished       map[string]string `json:"unfinished,omitempty"`
+	Skipped          []string          `json:"skipped,omitempty"`
+	Unmapped         []string          `json:"unmapped,omitempty"`
+}
+
+// A Migration replays a compiled collection into another Madek instance. It
+// re-creates the collection with its media entries, files and meta data on
+// the target instance. People and groups are mapped by id or name, keywords
+// by term. Media entries whose file already exists in the target collection
+// (matched by file name, size and checksum) are skipped. Created media
+// entries are only published once their meta data has been applied. Media
+// entries that could not be completed are recorded as unfinished and
+// completed by a run that reuses the report.
+type Migration struct {
+	// The client of the source instance.
+	Source *Client
+
+	// The client of the target instance.
+	Target *Client
+
+	// The id of an existing target collection. If empty, the collection of
+	// a previous report is used or a new one is created.
+	TargetCollection string
+
+	// The report of a previous migration. Its mappings and checksums are
+	// reused to avoid duplicates and rehashing files.
+	Previous *MigrationReport
+
+	// The function that is called to report progress.
+	Log func(msg string)
+}
+
+// Run will migrate the provided compiled collection and return a report of
+// the performed mapping. If the migration fails, the partial report is
+// returned together with the error.
+func (m *Migration) Run(coll *Collection) (*MigrationReport, error) {
+	// prepare report
+	report := &MigrationReport{
+		SourceCollection: coll.ID,
+		TargetCollection: m.TargetCollection,
+		MediaEntries:     map[string]string{},
+		People:           map[string]string{},
+		Groups:           map[string]string{},
+		Keywords:         map[string]string{},
+		Checksums:        map[string]string{},
+		Unfinished:       map[string]string{},
+	}
+
+	// copy previous report
+	if m.Previous != nil {
+		if report.TargetCollection == "" {
+			report.TargetCollection = m.Previous.TargetCollection
+		}
+		copyMap(report.MediaEntries, m.Previous.MediaEntries)
+		copyMap(report.People, m.Previous.People)
+		copyMap(report.Groups, m.Previous.Groups)
+		copyMap(report.Keywords, m.Previous.Keywords)
+		copyMap(report.Checksums, m.Previous.Checksums)
+		copyMap(report.Unfinished, m.Previous.Unfinished)
+	}
+
+	// create target collection
+	if report.TargetCollection == "" {
+		target, err := m.Target.CreateCollection("")
+		if err != nil {
+			return report, err
+		}
+		report.TargetCollection = target.ID
+		m.log("created collection %s", target.ID)
+	}
+
+	// apply collection meta data
+	err := m.applyMetaData(report, CollectionKind, report.TargetCollection, coll.MetaData)
+	if err != nil {
+		return report, err
+	}
+
+	// get target media entries
+	members, err := m.Target.mediaEntryIDs(report.TargetCollection)
+	if err != nil {
+		return report, err
+	}
+
+	// collect unfinished media entries
+	unfinished := map[string]bool{}
+	for _, id := range report.Unfinished {
+		unfinished[id] = true
+	}
+
+	// index target media entries by file name and size, unfinished media
+	// entries are completed instead of matched
+	index := map[string][]*MediaEntry{}
+	memberSet := map[string]bool{}
+	for _, id := range members {
+		memberSet[id] = true
+		if unfinished[id] {
+			continue
+		}
+		entry, err := m.Target.CompileMediaEntry(id)
+		if err != nil {
+			return report, err
+		}
+		index[fileKey(entry)] = append(index[fileKey(entry)], entry)
+	}
+
+	// migrate media entries
+	for _, entry := range coll.MediaEntries {
+		// skip media entries migrated by a previous run
+		if id, ok := report.MediaEntries[entry.ID]; ok && memberSet[id] {
+			m.log("skip %s (migrated as %s)", entry.ID, id)
+			report.Skipped = append(report.Skipped, entry.ID)
+			continue
+		}
+
+		// complete media entries left unfinished by a previous run
+		if id, ok := report.Unfinished[entry.ID]; ok && memberSet[id] {
+			m.log("complete %s (unfinished as %s)", entry.ID, id)
+			err = m.completeEntry(report, entry, id)
+			if err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		// migrate media entry
+		err = m.migrateEntry(report, entry, index)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+func (m *Migration) migrateEntry(report *MigrationReport, entry *MediaEntry, index map[string][]*MediaEntry) error {
+	// download file
+	file, err := ioutil.TempFile("", "madek-migration-*")
+	if err != nil {
+		return err
+	}
+
+	// ensure cleanup
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// download and hash file
+	hash := sha256.New()
+	err = m.Source.DownloadMediaFile(entry, io.MultiWriter(file, hash), nil)
+	if err != nil {
+		return err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	// skip existing entries with the same file name, size and checksum
+	for _, candidate := range index[fileKey(entry)] {
+		// hash missing checksums
+		if report.Checksums[candidate.ID] == "" {
+			report.Checksums[candidate.ID], err = m.checksum(m.Target, candidate)
+			if err != nil {
+				return err
+			}
+		}
+
+		// compare checksums
+		if report.Checksums[candidate.ID] == checksum {
+			m.log("skip %s (exists as %s)", entry.ID, candidate.ID)
+			report.MediaEntries[entry.ID] = candidate.ID
+			report.Skipped = append(report.Skipped, entry.ID)
+			return nil
+		}
+	}
+
+	// rewind file
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	// create media entry
+	created, err := m.Target.CreateMediaEntry(Upload{
+		FileName:    entry.FileName,
+		ContentType: entry.FileType,
+		Size:        entry.FileSize,
+		Body:        file,
+		Collection:  report.TargetCollection,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.log("created %s from %s", created.ID, entry.ID)
+
+	// update index
+	report.Checksums[created.ID] = checksum
+	report.Unfinished[entry.ID] = created.ID
+	index[fileKey(entry)] = append(index[fileKey(entry)], &MediaEntry{
+		ID:       created.ID,
+		FileName: entry.FileName,
+		FileSize: entry.FileSize,
+	})
+
+	return m.completeEntry(report, entry, created.ID)
+}
+
+func (m *Migration) completeEntry(report *MigrationReport, entry *MediaEntry, id string) error {
+	// apply meta data
+	err := m.applyMetaData(report, MediaEntryKind, id, entry.MetaData)
+	if err != nil {
+		return err
+	}
+
+	// publish media entry
+	err = m.Target.PublishMediaEntry(id)
+	if err != nil {
+		return err
+	}
+
+	// update report
+	delete(report.Unfinished, entry.ID)
+	report.MediaEntries[entry.ID] = id
+
+	return nil
+}
+
+func (m *Migration) applyMetaData(report *MigrationReport, kind Kind, id string, md *MetaData) error {
+	// check meta data
+	if md == nil {
+		return nil
+	}
+
+	// set texts
+	for _, text := range []struct {
+		key   string
+		value string
+	}{
+		{key: "madek_core:title", value: md.Title},
+		{key: "madek_core:subtitle", value: md.Subtitle},
+		{key: "madek_core:description", value: md.Description},
+		{key: "madek_core:portrayed_object_date", value: md.Year},
+		{key: "madek_core:copyright_notice", value: md.Copyright.Holder},
+		{key: "copyright:copyright_usage", value: md.Copyright.Usage},
+	} {
+		if text.value == "" {
+			continue
+		}
+		err := m.Target.SetText(kind, id, text.key, text.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// set keywords
+	for _, keywords := range []struct {
+		key   string
+		terms []string
+	}{
+		{key: "madek_core:keywords", terms: md.Keywords},
+		{key: "media_content:type", terms: md.Genres},
+		{key: "copyright:license", terms: md.Copyright.Licenses},
+	} {
+		if len(keywords.terms) == 0 {
+			continue
+		}
+		ids, err := m.mapKeywords(report, keywords.key, keywords.terms)
+		if err != nil {
+			return err
+		}
+		err = m.Target.putMetaDatum(kind, id, keywords.key, ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	// set authors
+	if len(md.Authors) > 0 {
+		var ids []string
+		for _, author := range md.Authors {
+			personID, err := m.mapPerson(report, author)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, personID)
+		}
+		err := m.Target.putMetaDatum(kind, id, "madek_core:authors", ids)
+		if err != nil {
+			return err
+		}
+	}
+
+	// set affiliation
+	if len(md.Affiliation) > 0 {
+		var ids []string
+		for _, group := range md.Affiliation {
+			groupID, err := m.mapGroup(report, group)
+			if err != nil {
+				return err
+			} else if groupID != "" {
+				ids = append(ids, groupID)
+			}
+		}
+		if len(ids) > 0 {
+			err := m.Target.putMetaDatum(kind, id, "zhdk_bereich:institutional_affiliation", ids)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Migration) mapKeywords(report *MigrationReport, metaKey string, terms []string) ([]string, error) {
+	// map terms
+	ids := make([]string, 0, len(terms))
+	for _, term := range terms {
+		// check report
+		key := metaKey + ":" + term
+		if id, ok := report.Keywords[key]; ok {
+			ids = append(ids, id)
+			continue
+		}
+
+		// resolve keyword
+		id, err := m.Target.ResolveKeyword(metaKey, term)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Keywords[key] = id
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (m *Migration) mapPerson(report *MigrationReport, author *Author) (string, error) {
+	// check report
+	if id, ok := report.People[author.ID]; ok {
+		return id, nil
+	}
+
+	// resolve by id
+	id, err := m.Target.ResolvePerson(author)
+	if err == ErrNotFound {
+		// resolve by name
+		id, err = m.Target.ResolvePerson(&Author{
+			FirstName: author.FirstName,
+			LastName:  author.LastName,
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	report.People[author.ID] = id
+
+	return id, nil
+}
+
+func (m *Migration) mapGroup(report *MigrationReport, group *Group) (string, error) {
+	// check report
+	if id, ok := report.Groups[group.ID]; ok {
+		return id, nil
+	}
+
+	// resolve by id
+	_, err := m.Target.GetGroup(group.ID)
+	if err == nil {
+		report.Groups[group.ID] = group.ID
+		return group.ID, nil
+	} else if err != ErrNotFound {
+		return "", err
+	}
+
+	// get search url
+	url, err := m.Target.Expand("people", map[string]string{
+		"search": group.Name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// search by name
+	pager := m.Target.Paginate(url, "people", 0)
+	for pager.Next() {
+		if pager.Item().Get("last_name").Str == group.Name {
+			report.Groups[group.ID] = pager.Item().Get("id").Str
+			return report.Groups[group.ID], nil
+		}
+	}
+	if pager.Error() != nil {
+		return "", pager.Error()
+	}
+
+	// groups are not created automatically
+	m.log("unmapped group %s", group.Name)
+	report.Unmapped = append(report.Unmapped, group.ID)
+
+	return "", nil
+}
+
+func (m *Migration) checksum(client *Client, entry *MediaEntry) (string, error) {
+	// hash file
+	hash := sha256.New()
+	err := client.DownloadMediaFile(entry, hash, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (m *Migration) log(format string, args ...interface{}) {
+	if m.Log != nil {
+		m.Log(fmt.Sprintf(format, args...))
+	}
+}
+
+func fileKey(entry *MediaEntry) string {
+	return fmt.Sprintf("%s:%d", entry.FileName, entry.FileSize)
+}
+
+func copyMap(dst, src map[string]string) {
+	for key, value := range src {
+		dst[key] = value
+	}
+}
@@ -0,0 +1,154 @@
+package madek
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigration(t *testing.T) {
+	source := fakeMadek("/api/")
+	source["/api/files/f1/data-stream"] = "image-bytes"
+	source["/api/files/f2/data-stream"] = "video-bytes-video-byte"
+	sourceServer := fakeAPI(t, source)
+
+	target := fakeMadek("/api/")
+	target["/api/files/f1/data-stream"] = "image-bytes"
+	target["/api/files/f2/data-stream"] = "video-bytes-video-byte"
+	target["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}]}`
+	target["/api/entries/e3"] = strings.Replace(target["/api/entries/e1"], `"id": "e1"`, `"id": "e3"`, 1)
+	target["/api/keywords/?meta_key_id=madek_core%3Akeywords&page=0&term=Design"] = `{"keywords": [{"id": "k9", "term": "Design"}]}`
+
+	var failing bool
+	var writes []string
+	targetServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET":
+			fakeHandler(target).ServeHTTP(w, r)
+		case r.Method == "POST" && r.URL.Path == "/api/entries/":
+			file, header, err := r.FormFile("file")
+			assert.NoError(t, err)
+			data, _ := ioutil.ReadAll(file)
+			writes = append(writes, "POST "+r.URL.String()+" "+header.Filename+":"+string(data))
+			if header.Filename == "image.jpg" {
+				_, _ = w.Write([]byte(`{"id": "e3"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": "e2"}`))
+		default:
+			data, _ := ioutil.ReadAll(r.Body)
+			writes = append(writes, r.Method+" "+r.URL.Path+" "+string(data))
+			if failing && r.URL.Path == "/api/media-entries/e3/meta-data/madek_core:title" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer targetServer.Close()
+
+	coll, err := NewClient(sourceServer.URL, "", "").CompileCollection("c1")
+	assert.NoError(t, err)
+
+	migration := &Migration{
+		Source:           NewClient(sourceServer.URL, "", ""),
+		Target:           NewClient(targetServer.URL, "", ""),
+		TargetCollection: "c1",
+	}
+
+	report, err := migration.Run(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, &MigrationReport{
+		SourceCollection: "c1",
+		TargetCollection: "c1",
+		MediaEntries:     map[string]string{"e1": "e1", "e2": "e2"},
+		People:           map[string]string{"a1": "a1"},
+		Groups:           map[string]string{},
+		Keywords:         map[string]string{"madek_core:keywords:Design": "k9"},
+		Checksums: map[string]string{
+			"e1": "2c8648d103e3dd7ad87660da0f126a1443b6d21ac1bd3ec000c5e24e2373a90c",
+			"e2": "70c32653e5b0fdb6039edc54bb5965d01df114961ec4c8dda54fa8db4b63cef2",
+		},
+		Unfinished: map[string]string{},
+		Skipped:    []string{"e1"},
+	}, report)
+	assert.Equal(t, []string{
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Collection"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:keywords {"value":["k9"]}`,
+		`PUT /api/collections/c1/meta-data/madek_core:authors {"value":["a1"]}`,
+		`POST /api/entries/ video.mp4:video-bytes-video-byte`,
+		`POST /api/collection-media-entry-arcs/ {"collection_id":"c1","media_entry_id":"e2"}`,
+		`PUT /api/media-entries/e2/meta-data/madek_core:title {"value":"Video"}`,
+		`PATCH /api/entries/e2 {"is_published":true}`,
+	}, writes)
+
+	target["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}, {"id": "e2"}]}`
+	writes = nil
+
+	migration.Previous = report
+	report, err = migration.Run(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e1", "e2"}, report.Skipped)
+	assert.Equal(t, []string{
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Collection"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:keywords {"value":["k9"]}`,
+		`PUT /api/collections/c1/meta-data/madek_core:authors {"value":["a1"]}`,
+	}, writes)
+
+	target["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}]}`
+	target["/api/files/f1"] = strings.Replace(target["/api/files/f1"], `"size": 11`, `"size": 12`, 1)
+	failing = true
+	writes = nil
+
+	migration.Previous = nil
+	report, err = migration.Run(coll)
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	assert.Equal(t, &MigrationReport{
+		SourceCollection: "c1",
+		TargetCollection: "c1",
+		MediaEntries:     map[string]string{},
+		People:           map[string]string{"a1": "a1"},
+		Groups:           map[string]string{},
+		Keywords:         map[string]string{"madek_core:keywords:Design": "k9"},
+		Checksums: map[string]string{
+			"e3": "2c8648d103e3dd7ad87660da0f126a1443b6d21ac1bd3ec000c5e24e2373a90c",
+		},
+		Unfinished: map[string]string{"e1": "e3"},
+	}, report)
+	assert.Equal(t, []string{
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Collection"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:keywords {"value":["k9"]}`,
+		`PUT /api/collections/c1/meta-data/madek_core:authors {"value":["a1"]}`,
+		`POST /api/entries/ image.jpg:image-bytes`,
+		`POST /api/collection-media-entry-arcs/ {"collection_id":"c1","media_entry_id":"e3"}`,
+		`PUT /api/media-entries/e3/meta-data/madek_core:title {"value":"Image"}`,
+	}, writes)
+
+	target["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}, {"id": "e3"}]}`
+	failing = false
+	writes = nil
+
+	migration.Previous = report
+	report, err = migration.Run(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"e1": "e3", "e2": "e2"}, report.MediaEntries)
+	assert.Equal(t, map[string]string{}, report.Unfinished)
+	assert.Empty(t, report.Skipped)
+	assert.Equal(t, []string{
+		`PUT /api/collections/c1/meta-data/madek_core:title {"value":"Collection"}`,
+		`PUT /api/collections/c1/meta-data/madek_core:keywords {"value":["k9"]}`,
+		`PUT /api/collections/c1/meta-data/madek_core:authors {"value":["a1"]}`,
+		`PUT /api/media-entries/e3/meta-data/madek_core:title {"value":"Image"}`,
+		`PUT /api/media-entries/e3/meta-data/madek_core:copyright_notice {"value":"Holder"}`,
+		`PATCH /api/entries/e3 {"is_published":true}`,
+		`POST /api/entries/ video.mp4:video-bytes-video-byte`,
+		`POST /api/collection-media-entry-arcs/ {"collection_id":"c1","media_entry_id":"e2"}`,
+		`PUT /api/media-entries/e2/meta-data/madek_core:title {"value":"Video"}`,
+		`PATCH /api/entries/e2 {"is_published":true}`,
+	}, writes)
+}
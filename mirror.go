@@ -0,0 +1,369 @@
+package madek
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestFile is the name of the manifest file in a mirror directory.
+const ManifestFile = "manifest.json"
+
+// A Manifest describes the contents of a mirror directory.
+type Manifest struct {
+	Collection *Collection               `json:"collection"`
+	Entries    map[string]*ManifestEntry `json:"entries"`
+	WrittenAt  time.Time                 `json:"written_at"`
+}
+
+// A ManifestEntry describes the mirrored files of a media entry. All paths
+// are relative to the mirror directory.
+type ManifestEntry struct {
+	Fingerprint string            `json:"fingerprint"`
+	FileID      string            `json:"file_id,omitempty"`
+	File        string            `json:"file,omitempty"`
+	Previews    map[string]string `json:"previews,omitempty"`
+}
+
+// LoadManifest will load the manifest from the provided mirror directory. It
+// returns nil if no manifest exists.
+func LoadManifest(dir string) (*Manifest, error) {
+	// read file
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// decode manifest
+	var manifest Manifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// A Mirror maintains a local copy of a collection including the original
+// files and selected previews of its media entries. Every media entry is
+// stored in its own directory named by its id. Subsequent syncs only download
+// entries that changed and remove entries that are no longer in the
+// collection.
+type Mirror struct {
+	// The client used to compile and download the collection.
+	Client *Client
+
+	// The mirror directory.
+	Directory string
+
+	// The previews to download. Every filter selects at most one preview per
+	// media entry.
+	Previews []PreviewFilter
+
+	// Whether the original files should not be downloaded.
+	SkipFiles bool
+
+	// The function that is called to report progress.
+	Log func(msg string)
+}
+
+// Sync will compile the specified collection and update the mirror directory.
+// It returns the written manifest.
+func (m *Mirror) Sync(collectionID string) (*Manifest, error) {
+	// compile collection
+	coll, err := m.Client.CompileCollection(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Update(coll)
+}
+
+// Update will update the mirror directory using the provided compiled
+// collection. It returns the written manifest.
+func (m *Mirror) Update(coll *Collection) (*Manifest, error) {
+	// ensure directory
+	err := os.MkdirAll(m.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// load previous manifest
+	previous, err := LoadManifest(m.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare manifest
+	manifest := &Manifest{
+		Collection: coll,
+		Entries:    map[string]*ManifestEntry{},
+	}
+
+	// sync media entries
+	for _, entry := range coll.MediaEntries {
+		// get previous entry
+		var old *ManifestEntry
+		if previous != nil {
+			old = previous.Entries[entry.ID]
+		}
+
+		// sync entry
+		manifest.Entries[entry.ID], err = m.syncEntry(entry, old)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// remove deleted entries
+	if previous != nil {
+		for id := range previous.Entries {
+			if _, ok := manifest.Entries[id]; !ok {
+				m.log("remove %s", id)
+				err = os.RemoveAll(filepath.Join(m.Directory, id))
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// write manifest
+	manifest.WrittenAt = time.Now().UTC()
+	err = writeJSON(filepath.Join(m.Directory, ManifestFile), manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func (m *Mirror) syncEntry(entry *MediaEntry, old *ManifestEntry) (*ManifestEntry, error) {
+	// compute fingerprint
+	fingerprint, err := Fingerprint(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare manifest entry
+	me := &ManifestEntry{
+		Fingerprint: fingerprint,
+		Previews:    map[string]string{},
+	}
+
+	// determine wanted files
+	wanted := map[string]func(*os.File) error{}
+	if !m.SkipFiles && entry.FileName != "" {
+		me.FileID = entry.FileID
+		me.File = filepath.Join(entry.ID, sanitizeFileName(entry.FileName))
+		wanted[me.File] = func(f *os.File) error {
+			return m.Client.DownloadMediaFile(entry, f, nil)
+		}
+	}
+	for _, filter := range m.Previews {
+		preview := entry.SelectPreview(filter)
+		if preview == nil {
+			continue
+		}
+		path := filepath.Join(entry.ID, "previews", preview.ID+extensionFor(preview.ContentType))
+		me.Previews[preview.ID] = path
+		wanted[path] = func(f *os.File) error {
+			return m.Client.DownloadPreview(preview, f, nil)
+		}
+	}
+
+	// check if unchanged
+	if old != nil && old.Fingerprint == fingerprint && m.present(wanted) {
+		return me, nil
+	}
+
+	m.log("sync %s", entry.ID)
+
+	// ensure directory
+	err = os.MkdirAll(filepath.Join(m.Directory, entry.ID, "previews"), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	// write entry
+	err = writeJSON(filepath.Join(m.Directory, entry.ID, "entry.json"), entry)
+	if err != nil {
+		return nil, err
+	}
+
+	// remove replaced original files including partial downloads as the
+	// path does not change if the new file has the same name
+	if old != nil && old.File != "" && old.FileID != me.FileID {
+		for _, path := range []string{old.File, old.File + ".part", me.File + ".part"} {
+			err = os.Remove(filepath.Join(m.Directory, path))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+	}
+
+	// remove files that are no longer wanted
+	if old != nil {
+		for _, path := range append([]string{old.File}, mapValues(old.Previews)...) {
+			if _, ok := wanted[path]; !ok && path != "" {
+				err = os.Remove(filepath.Join(m.Directory, path))
+				if err != nil && !os.IsNotExist(err) {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// sort paths
+	paths := make([]string, 0, len(wanted))
+	for path := range wanted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// download missing files
+	for _, path := range paths {
+		// skip existing files as previews and files are immutable
+		if m.exists(path) {
+			continue
+		}
+
+		m.log("download %s", path)
+
+		err = m.download(path, wanted[path])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return me, nil
+}
+
+func (m *Mirror) download(path string, fn func(*os.File) error) error {
+	// open partial file
+	full := filepath.Join(m.Directory, path)
+	file, err := os.OpenFile(full+".part", os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	// download
+	err = fn(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	// close file
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(full+".part", full)
+}
+
+func (m *Mirror) present(files map[string]func(*os.File) error) bool {
+	// check files
+	for path := range files {
+		if !m.exists(path) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Mirror) exists(path string) bool {
+	_, err := os.Stat(filepath.Join(m.Directory, path))
+	return err == nil
+}
+
+func (m *Mirror) log(format string, args ...interface{}) {
+	if m.Log != nil {
+		m.Log(fmt.Sprintf(format, args...))
+	}
+}
+
+// Fingerprint will return a hash of the provided value's JSON encoding.
+func Fingerprint(value interface{}) (string, error) {
+	// encode value
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	// hash data
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func writeJSON(path string, value interface{}) error {
+	// encode value
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write atomically
+	err = ioutil.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func sanitizeFileName(name string) string {
+	// replace separators
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+
+	// handle special names
+	if name == "" || name == "." || name == ".." || name == "entry.json" || name == "previews" {
+		name = "_" + name
+	}
+
+	return name
+}
+
+func extensionFor(contentType string) string {
+	// get subtype
+	i := strings.IndexByte(contentType, '/')
+	if i < 0 {
+		return ""
+	}
+	sub := contentType[i+1:]
+
+	// handle special cases
+	switch sub {
+	case "jpeg":
+		return ".jpg"
+	case "quicktime":
+		return ".mov"
+	case "mpeg":
+		if strings.HasPrefix(contentType, "audio/") {
+			return ".mp3"
+		}
+	}
+
+	return "." + sub
+}
+
+func mapValues(m map[string]string) []string {
+	list := make([]string, 0, len(m))
+	for _, value := range m {
+		list = append(list, value)
+	}
+
+	return list
+}
@@ -0,0 +1,99 @@
+package madek
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/files/f1/data-stream"] = "image-bytes"
+	routes["/api/files/f2/data-stream"] = "video-bytes-video-byte"
+	routes["/media/p2"] = "preview"
+	routes["/media/p4"] = "preview"
+	routes["/media/p6"] = "preview"
+
+	server := fakeAPI(t, routes)
+	client := NewClient(server.URL, "", "")
+
+	var log []string
+	mirror := &Mirror{
+		Client:    client,
+		Directory: t.TempDir(),
+		Previews: []PreviewFilter{
+			{Type: "image", Width: 600},
+			{ContentType: "video/webm"},
+		},
+		Log: func(msg string) {
+			log = append(log, msg)
+		},
+	}
+
+	manifest, err := mirror.Sync("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", manifest.Collection.ID)
+	assert.Equal(t, filepath.Join("e1", "image.jpg"), manifest.Entries["e1"].File)
+	assert.Equal(t, map[string]string{
+		"p2": filepath.Join("e1", "previews", "p2.jpg"),
+	}, manifest.Entries["e1"].Previews)
+	assert.Equal(t, map[string]string{
+		"p4": filepath.Join("e2", "previews", "p4.jpg"),
+		"p6": filepath.Join("e2", "previews", "p6.webm"),
+	}, manifest.Entries["e2"].Previews)
+	assert.Equal(t, []string{
+		"sync e1",
+		"download " + filepath.Join("e1", "image.jpg"),
+		"download " + filepath.Join("e1", "previews", "p2.jpg"),
+		"sync e2",
+		"download " + filepath.Join("e2", "previews", "p4.jpg"),
+		"download " + filepath.Join("e2", "previews", "p6.webm"),
+		"download " + filepath.Join("e2", "video.mp4"),
+	}, log)
+
+	data, err := ioutil.ReadFile(filepath.Join(mirror.Directory, "e1", "image.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "image-bytes", string(data))
+
+	loaded, err := LoadManifest(mirror.Directory)
+	assert.NoError(t, err)
+	assert.Equal(t, manifest.Entries, loaded.Entries)
+
+	log = nil
+	_, err = mirror.Sync("c1")
+	assert.NoError(t, err)
+	assert.Empty(t, log)
+
+	routes["/api/entries/?collection_id=c1&page=0"] = `{"media-entries": [{"id": "e1"}]}`
+	routes["/api/data/m4"] = `{"type": "MetaDatum::Text", "value": "Changed"}`
+
+	log = nil
+	manifest, err = mirror.Sync("c1")
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Entries, 1)
+	assert.Equal(t, []string{"sync e1", "remove e2"}, log)
+
+	_, err = os.Stat(filepath.Join(mirror.Directory, "e2"))
+	assert.True(t, os.IsNotExist(err))
+
+	routes["/api/files/f1"] = strings.Replace(strings.Replace(routes["/api/files/f1"], `"id": "f1"`, `"id": "f9"`, 1), `"size": 11`, `"size": 9`, 1)
+	routes["/api/files/f1/data-stream"] = "new-image"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(mirror.Directory, "e1", "image.jpg.part"), []byte("stale"), 0644))
+
+	log = nil
+	manifest, err = mirror.Sync("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "f9", manifest.Entries["e1"].FileID)
+	assert.Equal(t, []string{
+		"sync e1",
+		"download " + filepath.Join("e1", "image.jpg"),
+	}, log)
+
+	data, err = ioutil.ReadFile(filepath.Join(mirror.Directory, "e1", "image.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new-image", string(data))
+}
@@ -1,6 +1,9 @@
 package madek
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 var supportedMetaKeys = []string{
 	"madek_core:title",
@@ -16,6 +19,17 @@ var supportedMetaKeys = []string{
 	"zhdk_bereich:institutional_affiliation",
 }
 
+// A Kind describes the kind of a resource.
+type Kind string
+
+// The available resource kinds.
+const (
+	CollectionKind Kind = "collection"
+	MediaEntryKind Kind = "media-entry"
+	PersonKind     Kind = "person"
+	KeywordKind    Kind = "keyword"
+)
+
 // Author contains info about an author.
 type Author struct {
 	ID        string `json:"id,omitempty"`
@@ -23,6 +37,11 @@ type Author struct {
 	LastName  string `json:"last_name,omitempty"`
 }
 
+// Name will return the full name of the author.
+func (a *Author) Name() string {
+	return strings.TrimSpace(a.FirstName + " " + a.LastName)
+}
+
 // Group contains info about a group.
 type Group struct {
 	ID        string `json:"id,omitempty"`
@@ -30,6 +49,12 @@ type Group struct {
 	Pseudonym string `json:"pseudonym,omitempty"`
 }
 
+// Keyword contains info about a keyword.
+type Keyword struct {
+	ID   string `json:"id"`
+	Term string `json:"term"`
+}
+
 // Copyright contains copyright infos.
 type Copyright struct {
 	Holder   string   `json:"holder,omitempty"`
@@ -52,33 +77,92 @@ type MetaData struct {
 
 // A Collection contains multiple media entries.
 type Collection struct {
-	ID           string        `json:"id"`
-	CreatedAt    time.Time     `json:"created_at"`
-	MetaData     *MetaData     `json:"meta_data"`
-	MediaEntries []*MediaEntry `json:"media_entries"`
+	ID                   string        `json:"id"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UpdatedAt            *time.Time    `json:"updated_at,omitempty"`
+	MetaDataUpdatedAt    *time.Time    `json:"meta_data_updated_at,omitempty"`
+	EditSessionUpdatedAt *time.Time    `json:"edit_session_updated_at,omitempty"`
+	MetaData             *MetaData     `json:"meta_data"`
+	Permissions          *Permissions  `json:"permissions,omitempty"`
+	MediaEntries         []*MediaEntry `json:"media_entries"`
+}
+
+// ModifiedAt will return the latest of the update times.
+func (c *Collection) ModifiedAt() time.Time {
+	return latestTime(c.CreatedAt, timeValue(c.UpdatedAt), timeValue(c.MetaDataUpdatedAt), timeValue(c.EditSessionUpdatedAt))
 }
 
 // A MediaEntry contains multiple previews.
 type MediaEntry struct {
-	ID          string     `json:"id"`
-	MetaData    *MetaData  `json:"meta_data"`
-	CreatedAt   time.Time  `json:"created_at"`
-	FileID      string     `json:"file_id"`
-	FileName    string     `json:"file_name"`
-	FileType    string     `json:"file_type"`
-	FileSize    int64      `json:"file_size"`
-	StreamURL   string     `json:"stream_url"`
-	DownloadURL string     `json:"download_url"`
-	Previews    []*Preview `json:"previews"`
+	ID                   string       `json:"id"`
+	MetaData             *MetaData    `json:"meta_data"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            *time.Time   `json:"updated_at,omitempty"`
+	MetaDataUpdatedAt    *time.Time   `json:"meta_data_updated_at,omitempty"`
+	EditSessionUpdatedAt *time.Time   `json:"edit_session_updated_at,omitempty"`
+	FileID               string       `json:"file_id"`
+	FileName             string       `json:"file_name"`
+	FileType             string       `json:"file_type"`
+	FileSize             int64        `json:"file_size"`
+	StreamURL            string       `json:"stream_url"`
+	DownloadURL          string       `json:"download_url"`
+	Previews             []*Preview   `json:"previews"`
+	Permissions          *Permissions `json:"permissions,omitempty"`
+}
+
+// ModifiedAt will return the latest of the update times.
+func (e *MediaEntry) ModifiedAt() time.Time {
+	return latestTime(e.CreatedAt, timeValue(e.UpdatedAt), timeValue(e.MetaDataUpdatedAt), timeValue(e.EditSessionUpdatedAt))
+}
+
+// Permissions describe who may access a media entry or collection.
+type Permissions struct {
+	Public          bool          `json:"public"`
+	PublicFullSize  bool          `json:"public_full_size,omitempty"`
+	ResponsibleUser string        `json:"responsible_user,omitempty"`
+	Users           []*Permission `json:"users,omitempty"`
+	Groups          []*Permission `json:"groups,omitempty"`
+	APIClients      []*Permission `json:"api_clients,omitempty"`
+}
+
+// A Permission grants a user, group or API client access to a resource.
+type Permission struct {
+	ID              string `json:"id"`
+	View            bool   `json:"view"`
+	ViewFullSize    bool   `json:"view_full_size,omitempty"`
+	EditMetaData    bool   `json:"edit_meta_data,omitempty"`
+	EditPermissions bool   `json:"edit_permissions,omitempty"`
 }
 
 // A Preview is the final accessible media.
 type Preview struct {
-	ID          string `json:"id"`
-	Type        string `json:"type"`
-	ContentType string `json:"content_type"`
-	Size        string `json:"size"`
-	Width       int    `json:"width"`
-	Height      int    `json:"height"`
-	URL         string `json:"url"`
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	ContentType string  `json:"content_type"`
+	Size        string  `json:"size"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	Duration    float64 `json:"duration,omitempty"`
+	URL         string  `json:"url"`
+}
+
+func latestTime(times ...time.Time) time.Time {
+	// find latest
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest
+}
+
+func timeValue(t *time.Time) time.Time {
+	// check nil
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
 }
@@ -0,0 +1,218 @@
+package madek
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// defaultRelations are used if the API root does not announce a relation. The
+// templates are relative to the API root.
+var defaultRelations = map[string]string{
+	"collection":    "collections/{id}",
+	"collections":   "collections/{?page,count}",
+	"media-entry":   "media-entries/{id}",
+	"media-entries": "media-entries/{?collection_id,page,count}",
+	"media-file":    "media-files/{id}",
+	"meta-datum":    "meta-data/{id}",
+	"preview":       "previews/{id}",
+	"person":        "people/{id}",
+	"keyword":       "keywords/{id}",
+	"keywords":      "keywords/{?meta_key_id,term}",
+	"license":       "licenses/{id}",
+	"meta-key":      "meta-keys/{id}",
+	"people":        "people/{?search}",
+
+	"collection-media-entry-arc":  "collection-media-entry-arcs/{id}",
+	"collection-media-entry-arcs": "collection-media-entry-arcs/{?collection_id,media_entry_id}",
+
+	"collection-meta-data":   "collections/{id}/meta-data/",
+	"media-entry-meta-data":  "media-entries/{id}/meta-data/",
+	"media-file-data-stream": "media-files/{id}/data-stream",
+
+	"collection-meta-datum":  "collections/{id}/meta-data/{meta_key_id}",
+	"media-entry-meta-datum": "media-entries/{id}/meta-data/{meta_key_id}",
+
+	"collection-permissions":             "collections/{id}/perms/resource",
+	"collection-user-permissions":        "collections/{id}/perms/users/",
+	"collection-group-permissions":       "collections/{id}/perms/groups/",
+	"collection-api-client-permissions":  "collections/{id}/perms/api-clients/",
+	"media-entry-permissions":            "media-entries/{id}/perms/resource",
+	"media-entry-user-permissions":       "media-entries/{id}/perms/users/",
+	"media-entry-group-permissions":      "media-entries/{id}/perms/groups/",
+	"media-entry-api-client-permissions": "media-entries/{id}/perms/api-clients/",
+}
+
+// Relations will discover and return the relations announced by the API root.
+// Relations missing from the root are complemented by defaults. The result is
+// cached for the lifetime of the client.
+func (c *Client) Relations() (map[string]string, error) {
+	// acquire mutex
+	c.relMutex.Lock()
+	defer c.relMutex.Unlock()
+
+	// check cache
+	if c.relations != nil {
+		return c.relations, nil
+	}
+
+	// prepare relations
+	relations := make(map[string]string)
+
+	// fetch root
+	rootStr, err := c.Fetch(c.address + c.Root)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	// collect announced relations
+	gjson.Get(rootStr, "_json-roa.relations").ForEach(func(key, value gjson.Result) bool {
+		if href := value.Get("href").Str; href != "" {
+			relations[relationName(key.Str)] = c.resolve(href)
+		}
+		return true
+	})
+
+	// add defaults
+	for name, tpl := range defaultRelations {
+		if _, ok := relations[name]; !ok {
+			relations[name] = c.address + c.Root + tpl
+		}
+	}
+
+	// cache relations
+	c.relations = relations
+
+	return relations, nil
+}
+
+// Expand will expand the named root relation using the provided parameters.
+func (c *Client) Expand(relation string, params map[string]string) (string, error) {
+	// get relations
+	relations, err := c.Relations()
+	if err != nil {
+		return "", err
+	}
+
+	// get template
+	tpl, ok := relations[relation]
+	if !ok {
+		return "", fmt.Errorf("missing relation: %s", relation)
+	}
+
+	return expandTemplate(tpl, params), nil
+}
+
+// Follow will expand the named relation of the provided JSON-ROA document
+// using the provided parameters.
+func (c *Client) Follow(doc, relation string, params map[string]string) (string, error) {
+	// find relation
+	var href string
+	gjson.Get(doc, "_json-roa.relations").ForEach(func(key, value gjson.Result) bool {
+		if relationName(key.Str) == relation {
+			href = value.Get("href").Str
+			return false
+		}
+		return true
+	})
+
+	// check href
+	if href == "" {
+		return "", fmt.Errorf("missing relation: %s", relation)
+	}
+
+	return expandTemplate(c.resolve(href), params), nil
+}
+
+// followOrExpand will follow the named relation of the provided document or
+// expand the named fallback root relation if the document does not announce
+// the relation.
+func (c *Client) followOrExpand(doc, relation, fallback string, params map[string]string) (string, error) {
+	// follow relation
+	url, err := c.Follow(doc, relation, nil)
+	if err == nil {
+		return url, nil
+	}
+
+	return c.Expand(fallback, params)
+}
+
+// WebURL will return the URL of the web page of the specified resource on the
+// Madek instance with the provided address.
+func WebURL(address string, kind Kind, id string) string {
+	// get path
+	path := "/entries/"
+	if kind == CollectionKind {
+		path = "/sets/"
+	}
+
+	return strings.TrimSuffix(address, "/") + path + id
+}
+
+func relationName(key string) string {
+	// strip namespace prefixes like "madek:api/"
+	if i := strings.LastIndexAny(key, ":/"); i >= 0 {
+		return key[i+1:]
+	}
+
+	return key
+}
+
+func expandTemplate(tpl string, params map[string]string) string {
+	// prepare builder
+	var b strings.Builder
+
+	for {
+		// find expression
+		start := strings.IndexByte(tpl, '{')
+		end := strings.IndexByte(tpl, '}')
+		if start < 0 || end < start {
+			b.WriteString(tpl)
+			break
+		}
+
+		// get expression and advance
+		b.WriteString(tpl[:start])
+		expr := tpl[start+1 : end]
+		tpl = tpl[end+1:]
+
+		// get operator
+		var op byte
+		if expr != "" && (expr[0] == '?' || expr[0] == '&') {
+			op = expr[0]
+			expr = expr[1:]
+		}
+
+		// expand variables
+		first := true
+		for _, name := range strings.Split(expr, ",") {
+			// get value
+			value, ok := params[name]
+			if !ok {
+				continue
+			}
+
+			// write value
+			switch {
+			case op != 0:
+				if first && op == '?' && !strings.Contains(b.String(), "?") {
+					b.WriteByte('?')
+				} else {
+					b.WriteByte('&')
+				}
+				b.WriteString(url.QueryEscape(name) + "=" + url.QueryEscape(value))
+			default:
+				if !first {
+					b.WriteByte(',')
+				}
+				b.WriteString(url.PathEscape(value))
+			}
+
+			first = false
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	assert.Equal(t, "/api/media-entries/e1", expandTemplate("/api/media-entries/{id}", map[string]string{
+		"id": "e1",
+	}))
+
+	assert.Equal(t, "/api/media-entries/?collection_id=c1&page=2", expandTemplate("/api/media-entries/{?collection_id,order,page}", map[string]string{
+		"collection_id": "c1",
+		"page":          "2",
+	}))
+
+	assert.Equal(t, "/api/media-entries/?public=true&collection_id=c1", expandTemplate("/api/media-entries/?public=true{&collection_id}", map[string]string{
+		"collection_id": "c1",
+	}))
+
+	assert.Equal(t, "/api/media-entries/", expandTemplate("/api/media-entries/{?collection_id}", nil))
+}
+
+func TestNavigation(t *testing.T) {
+	server := fakeAPI(t, fakeMadek("/v2/"))
+
+	client := NewClient(server.URL, "", "")
+	client.Root = "/v2/"
+
+	url, err := client.Expand("media-entry", map[string]string{"id": "e1"})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/v2/entries/e1", url)
+
+	url, err = client.Expand("license", map[string]string{"id": "l1"})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/v2/licenses/l1", url)
+
+	_, err = client.Expand("foo", nil)
+	assert.Error(t, err)
+
+	coll, err := client.CompileCollection("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Collection", coll.MetaData.Title)
+	assert.Equal(t, []string{"Design"}, coll.MetaData.Keywords)
+	assert.Len(t, coll.MediaEntries, 2)
+	assert.Equal(t, "Image", coll.MediaEntries[0].MetaData.Title)
+	assert.Equal(t, "Holder", coll.MediaEntries[0].MetaData.Copyright.Holder)
+	assert.Equal(t, server.URL+"/v2/files/f1/data-stream", coll.MediaEntries[0].StreamURL)
+	assert.Len(t, coll.MediaEntries[1].Previews, 4)
+}
+
+func TestNavigationFallback(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/entries/e1"] = `{"id": "e1", "created_at": "2016-05-25T09:55:01Z", "_json-roa": {"relations": {"media-file": {"href": "/api/files/f1"}}}}`
+	routes["/api/files/f1"] = `{"id": "f1", "filename": "image.jpg", "content_type": "image/jpeg", "size": 11, "previews": []}`
+	routes["/api/media-entries/e1/meta-data/"] = routes["/api/entries/e1/meta-data/"]
+
+	server := fakeAPI(t, routes)
+	client := NewClient(server.URL, "", "")
+
+	entry, err := client.CompileMediaEntry("e1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Image", entry.MetaData.Title)
+	assert.Equal(t, server.URL+"/api/media-files/f1/data-stream", entry.StreamURL)
+}
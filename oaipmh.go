@@ -0,0 +1,506 @@
+package madek
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OAINamespace is the namespace of OAI-PMH responses.
+const OAINamespace = "http://www.openarchives.org/OAI/2.0/"
+
+const oaiTimeFormat = "2006-01-02T15:04:05Z"
+
+// An OAIProvider is a http.Handler that serves the media entries of compiled
+// collections over OAI-PMH 2.0 in the oai_dc format. Collections are exposed
+// as sets. Media entries whose permissions have been compiled and are not
+// public are omitted.
+type OAIProvider struct {
+	// The function that returns the served collections.
+	Collections func() ([]*Collection, error)
+
+	// The name of the repository.
+	RepositoryName string
+
+	// The public URL of the endpoint.
+	BaseURL string
+
+	// The email addresses of the administrators.
+	AdminEmails []string
+
+	// The address of the Madek instance used to add web page identifiers.
+	Address string
+
+	// The prefix of item identifiers. Defaults to "oai:madek:".
+	IdentifierPrefix string
+
+	// The number of records per response. Defaults to 100.
+	PageSize int
+}
+
+type oaiResponse struct {
+	XMLName        xml.Name        `xml:"OAI-PMH"`
+	Namespace      string          `xml:"xmlns,attr"`
+	NamespaceXSI   string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	ResponseDate   string          `xml:"responseDate"`
+	Request        oaiRequest      `xml:"request"`
+	Errors         []oaiError      `xml:"error,omitempty"`
+	Identify       *oaiIdentify    `xml:"Identify,omitempty"`
+	ListFormats    *oaiListFormats `xml:"ListMetadataFormats,omitempty"`
+	ListSets       *oaiListSets    `xml:"ListSets,omitempty"`
+	ListIDs        *oaiListRecords `xml:"ListIdentifiers,omitempty"`
+	ListRecords    *oaiListRecords `xml:"ListRecords,omitempty"`
+	GetRecord      *oaiListRecords `xml:"GetRecord,omitempty"`
+}
+
+type oaiRequest struct {
+	Verb           string `xml:"verb,attr,omitempty"`
+	Identifier     string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix string `xml:"metadataPrefix,attr,omitempty"`
+	From           string `xml:"from,attr,omitempty"`
+	Until          string `xml:"until,attr,omitempty"`
+	Set            string `xml:"set,attr,omitempty"`
+	Token          string `xml:"resumptionToken,attr,omitempty"`
+	URL            string `xml:",chardata"`
+}
+
+type oaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+type oaiIdentify struct {
+	RepositoryName    string   `xml:"repositoryName"`
+	BaseURL           string   `xml:"baseURL"`
+	ProtocolVersion   string   `xml:"protocolVersion"`
+	AdminEmails       []string `xml:"adminEmail"`
+	EarliestDatestamp string   `xml:"earliestDatestamp"`
+	DeletedRecord     string   `xml:"deletedRecord"`
+	Granularity       string   `xml:"granularity"`
+}
+
+type oaiListFormats struct {
+	Formats []oaiFormat `xml:"metadataFormat"`
+}
+
+type oaiFormat struct {
+	Prefix    string `xml:"metadataPrefix"`
+	Schema    string `xml:"schema"`
+	Namespace string `xml:"metadataNamespace"`
+}
+
+type oaiListSets struct {
+	Sets []oaiSet `xml:"set"`
+}
+
+type oaiSet struct {
+	Spec string `xml:"setSpec"`
+	Name string `xml:"setName"`
+}
+
+type oaiListRecords struct {
+	Headers []*oaiHeader   `xml:"header,omitempty"`
+	Records []*oaiRecord   `xml:"record,omitempty"`
+	Token   *oaiResumption `xml:"resumptionToken,omitempty"`
+}
+
+type oaiRecord struct {
+	Header   *oaiHeader `xml:"header"`
+	Metadata struct {
+		Record *DCRecord
+	} `xml:"metadata"`
+}
+
+type oaiHeader struct {
+	Identifier string   `xml:"identifier"`
+	Datestamp  string   `xml:"datestamp"`
+	SetSpecs   []string `xml:"setSpec"`
+}
+
+type oaiResumption struct {
+	CompleteListSize int    `xml:"completeListSize,attr"`
+	Cursor           int    `xml:"cursor,attr"`
+	Token            string `xml:",chardata"`
+}
+
+type oaiItem struct {
+	entry *MediaEntry
+	sets  []string
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (p *OAIProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// parse form
+	_ = r.ParseForm()
+
+	// prepare response
+	res := &oaiResponse{
+		Namespace:      OAINamespace,
+		NamespaceXSI:   "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: OAINamespace + " http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd",
+		ResponseDate:   time.Now().UTC().Format(oaiTimeFormat),
+		Request: oaiRequest{
+			URL: p.BaseURL,
+		},
+	}
+
+	// handle request
+	err := p.handle(r.Form, res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write response
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func (p *OAIProvider) handle(form url.Values, res *oaiResponse) error {
+	// check arguments
+	verb := form.Get("verb")
+	allowed := map[string][]string{
+		"Identify":            nil,
+		"ListMetadataFormats": {"identifier"},
+		"ListSets":            {"resumptionToken"},
+		"ListIdentifiers":     {"metadataPrefix", "from", "until", "set", "resumptionToken"},
+		"ListRecords":         {"metadataPrefix", "from", "until", "set", "resumptionToken"},
+		"GetRecord":           {"identifier", "metadataPrefix"},
+	}
+	args, ok := allowed[verb]
+	if !ok {
+		return p.fail(res, "badVerb", "illegal or missing verb")
+	}
+	for key, values := range form {
+		if key != "verb" && !stringInList(args, key) || len(values) > 1 {
+			return p.fail(res, "badArgument", "illegal or repeated argument: "+key)
+		}
+	}
+
+	// set request
+	res.Request.Verb = verb
+	res.Request.Identifier = form.Get("identifier")
+	res.Request.MetadataPrefix = form.Get("metadataPrefix")
+	res.Request.From = form.Get("from")
+	res.Request.Until = form.Get("until")
+	res.Request.Set = form.Get("set")
+	res.Request.Token = form.Get("resumptionToken")
+
+	// get items
+	items, sets, err := p.items()
+	if err != nil {
+		return err
+	}
+
+	// handle verb
+	switch verb {
+	case "Identify":
+		return p.identify(res, items)
+	case "ListMetadataFormats":
+		if id := form.Get("identifier"); id != "" && p.find(items, id) == nil {
+			return p.fail(res, "idDoesNotExist", "unknown identifier: "+id)
+		}
+		res.ListFormats = &oaiListFormats{
+			Formats: []oaiFormat{{
+				Prefix:    "oai_dc",
+				Schema:    OAIDCSchema,
+				Namespace: OAIDCNamespace,
+			}},
+		}
+	case "ListSets":
+		if form.Get("resumptionToken") != "" {
+			return p.fail(res, "badResumptionToken", "invalid resumption token")
+		}
+		if len(sets) == 0 {
+			return p.fail(res, "noSetHierarchy", "no sets available")
+		}
+		res.ListSets = &oaiListSets{Sets: sets}
+	case "ListIdentifiers", "ListRecords":
+		return p.list(res, form, items, verb == "ListRecords")
+	case "GetRecord":
+		if form.Get("identifier") == "" || form.Get("metadataPrefix") == "" {
+			return p.fail(res, "badArgument", "missing identifier or metadataPrefix")
+		}
+		if form.Get("metadataPrefix") != "oai_dc" {
+			return p.fail(res, "cannotDisseminateFormat", "unsupported metadata prefix")
+		}
+		item := p.find(items, form.Get("identifier"))
+		if item == nil {
+			return p.fail(res, "idDoesNotExist", "unknown identifier: "+form.Get("identifier"))
+		}
+		res.GetRecord = &oaiListRecords{
+			Records: []*oaiRecord{p.record(item)},
+		}
+	}
+
+	return nil
+}
+
+func (p *OAIProvider) identify(res *oaiResponse, items []*oaiItem) error {
+	// find earliest datestamp
+	earliest := time.Unix(0, 0).UTC()
+	for i, item := range items {
+		if t := item.entry.ModifiedAt(); i == 0 || t.Before(earliest) {
+			earliest = t
+		}
+	}
+
+	// set response
+	res.Identify = &oaiIdentify{
+		RepositoryName:    p.RepositoryName,
+		BaseURL:           p.BaseURL,
+		ProtocolVersion:   "2.0",
+		AdminEmails:       p.AdminEmails,
+		EarliestDatestamp: earliest.UTC().Format(oaiTimeFormat),
+		DeletedRecord:     "no",
+		Granularity:       "YYYY-MM-DDThh:mm:ssZ",
+	}
+
+	return nil
+}
+
+func (p *OAIProvider) list(res *oaiResponse, form url.Values, items []*oaiItem, records bool) error {
+	// get arguments
+	prefix := form.Get("metadataPrefix")
+	from := form.Get("from")
+	until := form.Get("until")
+	set := form.Get("set")
+	offset := 0
+
+	// decode resumption token
+	if token := form.Get("resumptionToken"); token != "" {
+		if len(form) > 2 {
+			return p.fail(res, "badArgument", "resumption token is exclusive")
+		}
+		var ok bool
+		prefix, from, until, set, offset, ok = decodeOAIToken(token)
+		if !ok {
+			return p.fail(res, "badResumptionToken", "invalid resumption token")
+		}
+	}
+
+	// check prefix
+	if prefix == "" {
+		return p.fail(res, "badArgument", "missing metadataPrefix")
+	} else if prefix != "oai_dc" {
+		return p.fail(res, "cannotDisseminateFormat", "unsupported metadata prefix")
+	}
+
+	// parse range
+	fromTime, err := parseOAITime(from, false)
+	if err != nil {
+		return p.fail(res, "badArgument", "invalid from: "+from)
+	}
+	untilTime, err := parseOAITime(until, true)
+	if err != nil {
+		return p.fail(res, "badArgument", "invalid until: "+until)
+	}
+
+	// filter items
+	var matches []*oaiItem
+	for _, item := range items {
+		t := item.entry.ModifiedAt().Truncate(time.Second)
+		if !fromTime.IsZero() && t.Before(fromTime) || !untilTime.IsZero() && t.After(untilTime) {
+			continue
+		}
+		if set != "" && !stringInList(item.sets, set) {
+			continue
+		}
+		matches = append(matches, item)
+	}
+	if len(matches) == 0 {
+		return p.fail(res, "noRecordsMatch", "no records match")
+	}
+
+	// check offset
+	if offset < 0 || offset >= len(matches) {
+		return p.fail(res, "badResumptionToken", "invalid resumption token")
+	}
+
+	// get page
+	size := p.PageSize
+	if size <= 0 {
+		size = 100
+	}
+	end := offset + size
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	// prepare list
+	list := &oaiListRecords{}
+	for _, item := range matches[offset:end] {
+		if records {
+			list.Records = append(list.Records, p.record(item))
+		} else {
+			list.Headers = append(list.Headers, p.header(item))
+		}
+	}
+
+	// add resumption token
+	if end < len(matches) || offset > 0 {
+		list.Token = &oaiResumption{
+			CompleteListSize: len(matches),
+			Cursor:           offset,
+		}
+		if end < len(matches) {
+			list.Token.Token = encodeOAIToken(prefix, from, until, set, end)
+		}
+	}
+
+	// set response
+	if records {
+		res.ListRecords = list
+	} else {
+		res.ListIDs = list
+	}
+
+	return nil
+}
+
+func (p *OAIProvider) items() ([]*oaiItem, []oaiSet, error) {
+	// get collections
+	colls, err := p.Collections()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// collect items and sets
+	var items []*oaiItem
+	var sets []oaiSet
+	index := map[string]*oaiItem{}
+	for _, coll := range colls {
+		// add set
+		name := coll.ID
+		if coll.MetaData != nil && coll.MetaData.Title != "" {
+			name = coll.MetaData.Title
+		}
+		sets = append(sets, oaiSet{Spec: coll.ID, Name: name})
+
+		// add items
+		for _, entry := range coll.MediaEntries {
+			// skip private entries
+			if entry.Permissions != nil && !entry.Permissions.Public {
+				continue
+			}
+
+			// add item
+			item, ok := index[entry.ID]
+			if !ok {
+				item = &oaiItem{entry: entry}
+				index[entry.ID] = item
+				items = append(items, item)
+			}
+			item.sets = append(item.sets, coll.ID)
+		}
+	}
+
+	// sort items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].entry.ID < items[j].entry.ID
+	})
+
+	return items, sets, nil
+}
+
+func (p *OAIProvider) find(items []*oaiItem, identifier string) *oaiItem {
+	// find item
+	for _, item := range items {
+		if p.identifier(item.entry) == identifier {
+			return item
+		}
+	}
+
+	return nil
+}
+
+func (p *OAIProvider) record(item *oaiItem) *oaiRecord {
+	// prepare record
+	record := &oaiRecord{
+		Header: p.header(item),
+	}
+	record.Metadata.Record = item.entry.DublinCore(p.Address)
+
+	return record
+}
+
+func (p *OAIProvider) header(item *oaiItem) *oaiHeader {
+	return &oaiHeader{
+		Identifier: p.identifier(item.entry),
+		Datestamp:  item.entry.ModifiedAt().UTC().Format(oaiTimeFormat),
+		SetSpecs:   item.sets,
+	}
+}
+
+func (p *OAIProvider) identifier(entry *MediaEntry) string {
+	// get prefix
+	prefix := p.IdentifierPrefix
+	if prefix == "" {
+		prefix = "oai:madek:"
+	}
+
+	return prefix + entry.ID
+}
+
+func (p *OAIProvider) fail(res *oaiResponse, code, message string) error {
+	// add error
+	res.Errors = append(res.Errors, oaiError{
+		Code:    code,
+		Message: message,
+	})
+
+	return nil
+}
+
+func parseOAITime(str string, end bool) (time.Time, error) {
+	// check empty
+	if str == "" {
+		return time.Time{}, nil
+	}
+
+	// parse day
+	if len(str) == len("2006-01-02") {
+		t, err := time.Parse("2006-01-02", str)
+		if err == nil && end {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t, err
+	}
+
+	return time.Parse(oaiTimeFormat, str)
+}
+
+func encodeOAIToken(prefix, from, until, set string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join([]string{
+		prefix, from, until, set, strconv.Itoa(offset),
+	}, "\n")))
+}
+
+func decodeOAIToken(token string) (string, string, string, string, int, bool) {
+	// decode token
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", "", "", 0, false
+	}
+
+	// split fields
+	fields := strings.Split(string(data), "\n")
+	if len(fields) != 5 {
+		return "", "", "", "", 0, false
+	}
+
+	// parse offset
+	offset, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return "", "", "", "", 0, false
+	}
+
+	return fields[0], fields[1], fields[2], fields[3], offset, true
+}
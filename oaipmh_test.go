@@ -0,0 +1,97 @@
+package madek
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAIProvider(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2016, 5, day, 10, 0, 0, 0, time.UTC)
+	}
+
+	colls := []*Collection{
+		{
+			ID:       "c1",
+			MetaData: &MetaData{Title: "First"},
+			MediaEntries: []*MediaEntry{
+				{ID: "e1", CreatedAt: date(1), MetaData: &MetaData{Title: "One"}},
+				{ID: "e2", CreatedAt: date(2), MetaData: &MetaData{Title: "Two"}},
+				{ID: "e3", CreatedAt: date(3), Permissions: &Permissions{}},
+			},
+		},
+		{
+			ID: "c2",
+			MediaEntries: []*MediaEntry{
+				{ID: "e2", CreatedAt: date(2), MetaData: &MetaData{Title: "Two"}},
+				{ID: "e4", CreatedAt: date(4), MetaData: &MetaData{Title: "Four"}},
+			},
+		},
+	}
+
+	provider := &OAIProvider{
+		Collections: func() ([]*Collection, error) {
+			return colls, nil
+		},
+		RepositoryName: "Archive",
+		BaseURL:        "https://example.com/oai",
+		AdminEmails:    []string{"admin@example.com"},
+		Address:        "https://madek.example.com",
+		PageSize:       2,
+	}
+
+	get := func(query string) string {
+		rec := httptest.NewRecorder()
+		provider.ServeHTTP(rec, httptest.NewRequest("GET", "/oai?"+query, nil))
+		assert.Equal(t, "text/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+		data, _ := ioutil.ReadAll(rec.Body)
+		return string(data)
+	}
+
+	res := get("verb=Identify")
+	assert.Contains(t, res, `<request verb="Identify">https://example.com/oai</request>`)
+	assert.Contains(t, res, `<repositoryName>Archive</repositoryName>`)
+	assert.Contains(t, res, `<adminEmail>admin@example.com</adminEmail>`)
+	assert.Contains(t, res, `<earliestDatestamp>2016-05-01T10:00:00Z</earliestDatestamp>`)
+
+	res = get("verb=ListSets")
+	assert.Contains(t, res, `<set><setSpec>c1</setSpec><setName>First</setName></set><set><setSpec>c2</setSpec><setName>c2</setName></set>`)
+
+	res = get("verb=ListMetadataFormats")
+	assert.Contains(t, res, `<metadataPrefix>oai_dc</metadataPrefix>`)
+
+	res = get("verb=ListRecords&metadataPrefix=oai_dc")
+	assert.Contains(t, res, `<header><identifier>oai:madek:e1</identifier><datestamp>2016-05-01T10:00:00Z</datestamp><setSpec>c1</setSpec></header>`)
+	assert.Contains(t, res, `<header><identifier>oai:madek:e2</identifier><datestamp>2016-05-02T10:00:00Z</datestamp><setSpec>c1</setSpec><setSpec>c2</setSpec></header>`)
+	assert.Contains(t, res, `<dc:title>One</dc:title>`)
+	assert.NotContains(t, res, `e3`)
+	assert.NotContains(t, res, `e4`)
+	token := regexp.MustCompile(`<resumptionToken completeListSize="3" cursor="0">([^<]+)</resumptionToken>`).FindStringSubmatch(res)
+	assert.Len(t, token, 2)
+
+	res = get("verb=ListRecords&resumptionToken=" + token[1])
+	assert.Contains(t, res, `<identifier>oai:madek:e4</identifier>`)
+	assert.Contains(t, res, `<resumptionToken completeListSize="3" cursor="2"></resumptionToken>`)
+
+	res = get("verb=ListIdentifiers&metadataPrefix=oai_dc&set=c2&from=2016-05-03")
+	assert.Contains(t, res, `<ListIdentifiers><header><identifier>oai:madek:e4</identifier>`)
+	assert.NotContains(t, res, `resumptionToken`)
+
+	res = get("verb=ListIdentifiers&metadataPrefix=oai_dc&until=2016-05-01")
+	assert.Contains(t, res, `<ListIdentifiers><header><identifier>oai:madek:e1</identifier><datestamp>2016-05-01T10:00:00Z</datestamp><setSpec>c1</setSpec></header></ListIdentifiers>`)
+
+	res = get("verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:madek:e2")
+	assert.Contains(t, res, `<dc:identifier>https://madek.example.com/entries/e2</dc:identifier>`)
+
+	assert.Contains(t, get("verb=Foo"), `<error code="badVerb">`)
+	assert.Contains(t, get("verb=ListRecords"), `<error code="badArgument">`)
+	assert.Contains(t, get("verb=ListRecords&metadataPrefix=mods"), `<error code="cannotDisseminateFormat">`)
+	assert.Contains(t, get("verb=ListRecords&resumptionToken=foo"), `<error code="badResumptionToken">`)
+	assert.Contains(t, get("verb=ListRecords&metadataPrefix=oai_dc&from=2017-01-01"), `<error code="noRecordsMatch">`)
+	assert.Contains(t, get("verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:madek:e3"), `<error code="idDoesNotExist">`)
+}
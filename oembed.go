@@ -0,0 +1,133 @@
+package madek
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// An OEmbed is an oEmbed 1.0 response.
+type OEmbed struct {
+	Type            string `json:"type"`
+	Version         string `json:"version"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	ProviderName    string `json:"provider_name,omitempty"`
+	ProviderURL     string `json:"provider_url,omitempty"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int    `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int    `json:"thumbnail_height,omitempty"`
+	URL             string `json:"url,omitempty"`
+	HTML            string `json:"html,omitempty"`
+	Width           int    `json:"width,omitempty"`
+	Height          int    `json:"height,omitempty"`
+}
+
+// OEmbed will return an oEmbed response for the media entry. Media entries
+// with public video previews become videos that embed a video element,
+// entries with public image previews become photos and all others links. The
+// largest previews that fit the provided maximum dimensions are selected.
+// Zero dimensions are ignored.
+func (e *MediaEntry) OEmbed(maxWidth, maxHeight int) *OEmbed {
+	// prepare response
+	res := &OEmbed{
+		Type:    "link",
+		Version: "1.0",
+	}
+
+	// add meta data
+	if md := e.MetaData; md != nil {
+		res.Title = md.Title
+		res.AuthorName = strings.Join(authorNames(md.Authors), ", ")
+	}
+
+	// get renditions
+	set := (&MediaEntry{Previews: e.PublicPreviews()}).Renditions()
+
+	// add thumbnail
+	if images := set.Images(); len(images) > 0 {
+		thumbWidth := 300
+		if maxWidth > 0 && maxWidth < thumbWidth {
+			thumbWidth = maxWidth
+		}
+		thumb := fitPreview(images[0].Previews, thumbWidth, maxHeight)
+		res.ThumbnailURL = thumb.URL
+		res.ThumbnailWidth, res.ThumbnailHeight = fitDimensions(thumb, thumbWidth, maxHeight)
+	}
+
+	// add video
+	if videos := set.Videos(); len(videos) > 0 {
+		// select sources
+		var sources []*Preview
+		for _, group := range videos {
+			sources = append(sources, fitPreview(group.Previews, maxWidth, maxHeight))
+		}
+
+		// set type and dimensions
+		res.Type = "video"
+		res.Width, res.Height = fitDimensions(sources[0], maxWidth, maxHeight)
+
+		// render html
+		var b strings.Builder
+		fmt.Fprintf(&b, `<video controls width="%d" height="%d"`, res.Width, res.Height)
+		if res.ThumbnailURL != "" {
+			fmt.Fprintf(&b, ` poster="%s"`, html.EscapeString(res.ThumbnailURL))
+		}
+		b.WriteString(">")
+		for _, source := range sources {
+			fmt.Fprintf(&b, `<source src="%s" type="%s">`, html.EscapeString(source.URL), html.EscapeString(source.ContentType))
+		}
+		b.WriteString("</video>")
+		res.HTML = b.String()
+
+		return res
+	}
+
+	// add photo
+	if images := set.Images(); len(images) > 0 {
+		preview := fitPreview(images[0].Previews, maxWidth, maxHeight)
+		res.Type = "photo"
+		res.URL = preview.URL
+		res.Width, res.Height = fitDimensions(preview, maxWidth, maxHeight)
+	}
+
+	return res
+}
+
+func fitPreview(previews []*Preview, maxWidth, maxHeight int) *Preview {
+	// find largest fitting and smallest preview
+	var largest, smallest *Preview
+	for _, preview := range previews {
+		if smallest == nil || previewLess(preview, smallest) {
+			smallest = preview
+		}
+		if maxWidth > 0 && preview.Width > maxWidth || maxHeight > 0 && preview.Height > maxHeight {
+			continue
+		}
+		if largest == nil || previewLess(largest, preview) {
+			largest = preview
+		}
+	}
+
+	// fall back to smallest preview
+	if largest == nil {
+		return smallest
+	}
+
+	return largest
+}
+
+func fitDimensions(preview *Preview, maxWidth, maxHeight int) (int, int) {
+	// scale down to fit
+	width, height := preview.Width, preview.Height
+	if maxWidth > 0 && width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if maxHeight > 0 && height > maxHeight {
+		width = width * maxHeight / height
+		height = maxHeight
+	}
+
+	return width, height
+}
@@ -0,0 +1,78 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaEntryOEmbed(t *testing.T) {
+	images := []*Preview{
+		{ID: "p1", Type: "image", ContentType: "image/jpeg", Width: 100, Height: 50, URL: "https://example.com/p1"},
+		{ID: "p2", Type: "image", ContentType: "image/jpeg", Width: 600, Height: 300, URL: "https://example.com/p2"},
+		{ID: "p3", Type: "image", ContentType: "image/jpeg", Width: 1200, Height: 600, URL: "https://example.com/p3"},
+	}
+
+	entry := &MediaEntry{
+		ID: "e1",
+		MetaData: &MetaData{
+			Title:   "Image",
+			Authors: []*Author{{FirstName: "Jane", LastName: "Doe"}},
+		},
+		Previews: images,
+	}
+
+	assert.Equal(t, &OEmbed{
+		Type:            "photo",
+		Version:         "1.0",
+		Title:           "Image",
+		AuthorName:      "Jane Doe",
+		ThumbnailURL:    "https://example.com/p1",
+		ThumbnailWidth:  100,
+		ThumbnailHeight: 50,
+		URL:             "https://example.com/p3",
+		Width:           1200,
+		Height:          600,
+	}, entry.OEmbed(0, 0))
+
+	res := entry.OEmbed(800, 0)
+	assert.Equal(t, "https://example.com/p2", res.URL)
+	assert.Equal(t, 600, res.Width)
+	assert.Equal(t, 300, res.Height)
+
+	res = entry.OEmbed(50, 0)
+	assert.Equal(t, "https://example.com/p1", res.URL)
+	assert.Equal(t, 50, res.Width)
+	assert.Equal(t, 25, res.Height)
+	assert.Equal(t, 50, res.ThumbnailWidth)
+	assert.Equal(t, 25, res.ThumbnailHeight)
+
+	entry = &MediaEntry{
+		ID: "e2",
+		Previews: append(images,
+			&Preview{ID: "p4", Type: "video", ContentType: "video/mp4", Width: 640, Height: 360, URL: "https://example.com/p4"},
+			&Preview{ID: "p5", Type: "video", ContentType: "video/mp4", Width: 1920, Height: 1080, URL: "https://example.com/p5"},
+			&Preview{ID: "p6", Type: "video", ContentType: "video/webm", Width: 1920, Height: 1080, URL: "https://example.com/p6"},
+		),
+	}
+
+	res = entry.OEmbed(1000, 0)
+	assert.Equal(t, "video", res.Type)
+	assert.Equal(t, 640, res.Width)
+	assert.Equal(t, 360, res.Height)
+	assert.Equal(t, `<video controls width="640" height="360" poster="https://example.com/p1">`+
+		`<source src="https://example.com/p4" type="video/mp4">`+
+		`<source src="https://example.com/p6" type="video/webm">`+
+		`</video>`, res.HTML)
+
+	entry = &MediaEntry{
+		ID:          "e3",
+		Previews:    images,
+		Permissions: &Permissions{},
+	}
+
+	assert.Equal(t, &OEmbed{
+		Type:    "link",
+		Version: "1.0",
+	}, entry.OEmbed(0, 0))
+}
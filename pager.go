@@ -0,0 +1,145 @@
+package madek
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// A Pager iterates over the items of a paginated JSON-ROA collection by
+// following the "next" links provided by the API.
+type Pager struct {
+	client *Client
+	key    string
+	next   string
+	page   int
+	items  []gjson.Result
+	item   gjson.Result
+	err    error
+}
+
+// Paginate will return a pager that iterates over the items listed under the
+// specified key of the JSON-ROA collection at the provided url. The iteration
+// starts at the specified page which allows resuming a previous iteration.
+func (c *Client) Paginate(url, key string, page int) *Pager {
+	// prepare query
+	query := map[string]string{
+		"page": strconv.Itoa(page),
+	}
+
+	// add page size hint
+	if c.PageSize > 0 {
+		query["count"] = strconv.Itoa(c.PageSize)
+	}
+
+	// prepare url
+	next, err := setQuery(url, query)
+
+	return &Pager{
+		client: c,
+		key:    key,
+		next:   next,
+		page:   page - 1,
+		err:    err,
+	}
+}
+
+// Next will advance the pager to the next item and fetch additional pages as
+// required. It returns false if all items have been iterated or an error
+// occurred.
+func (p *Pager) Next() bool {
+	// load pages until an item is available
+	for len(p.items) == 0 {
+		// check state
+		if p.err != nil || p.next == "" {
+			return false
+		}
+
+		// load next page
+		p.load()
+	}
+
+	// pop item
+	p.item = p.items[0]
+	p.items = p.items[1:]
+
+	return true
+}
+
+// Item will return the current item.
+func (p *Pager) Item() gjson.Result {
+	return p.item
+}
+
+// Page will return the page of the current item. It may be passed to
+// Paginate to resume an iteration.
+func (p *Pager) Page() int {
+	return p.page
+}
+
+// Error will return the error that stopped the iteration.
+func (p *Pager) Error() error {
+	return p.err
+}
+
+// IDs will collect the ids of all remaining items.
+func (p *Pager) IDs() ([]string, error) {
+	// collect ids
+	var ids []string
+	for p.Next() {
+		ids = append(ids, p.Item().Get("id").Str)
+	}
+
+	return ids, p.Error()
+}
+
+func (p *Pager) load() {
+	// fetch page
+	pageStr, err := p.client.Fetch(p.next)
+	if err != nil {
+		p.err = err
+		return
+	}
+
+	// determine page number
+	p.page++
+	if u, err := url.Parse(p.next); err == nil {
+		if n, err := strconv.Atoi(u.Query().Get("page")); err == nil {
+			p.page = n
+		}
+	}
+
+	// set items
+	p.items = gjson.Get(pageStr, p.key).Array()
+
+	// get next link
+	p.next = ""
+	if href := gjson.Get(pageStr, "_json-roa.collection.next.href").Str; href != "" {
+		p.next = p.client.resolve(href)
+	}
+
+	// stop on empty pages
+	if len(p.items) == 0 {
+		p.next = ""
+	}
+}
+
+func setQuery(str string, params map[string]string) (string, error) {
+	// parse url
+	u, err := url.Parse(str)
+	if err != nil {
+		return "", err
+	}
+
+	// set params
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	// encode query
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,43 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPager(t *testing.T) {
+	server := fakeAPI(t, map[string]string{
+		"/api/media-entries/?collection_id=c1&count=2&page=0": `{
+			"media-entries": [{"id": "e1"}, {"id": "e2"}],
+			"_json-roa": {"collection": {"next": {"href": "/api/media-entries/?collection_id=c1&count=2&page=1"}}}
+		}`,
+		"/api/media-entries/?collection_id=c1&count=2&page=1": `{
+			"media-entries": [{"id": "e3"}],
+			"_json-roa": {"collection": {}}
+		}`,
+	})
+
+	client := NewClient(server.URL, "", "")
+	client.PageSize = 2
+
+	pager := client.Paginate(client.URL("/api/media-entries/?collection_id=c1"), "media-entries", 0)
+
+	var ids []string
+	var pages []int
+	for pager.Next() {
+		ids = append(ids, pager.Item().Get("id").Str)
+		pages = append(pages, pager.Page())
+	}
+	assert.NoError(t, pager.Error())
+	assert.Equal(t, []string{"e1", "e2", "e3"}, ids)
+	assert.Equal(t, []int{0, 0, 1}, pages)
+
+	ids, err := client.Paginate(client.URL("/api/media-entries/?collection_id=c1"), "media-entries", 1).IDs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e3"}, ids)
+
+	ids, err = client.Paginate(client.URL("/api/media-entries/?collection_id=c2"), "media-entries", 0).IDs()
+	assert.Equal(t, ErrNotFound, err)
+	assert.Empty(t, ids)
+}
@@ -0,0 +1,94 @@
+package madek
+
+import (
+	"errors"
+
+	"github.com/tidwall/gjson"
+)
+
+// GetPermissions will fetch the permissions of the specified resource. Lists
+// of user, group and API client permissions that may not be read with the
+// current credentials are left empty.
+func (c *Client) GetPermissions(kind Kind, id string) (*Permissions, error) {
+	// fetch resource permissions
+	resourceStr, err := c.fetchByID(string(kind)+"-permissions", id)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare permissions
+	perms := &Permissions{
+		Public:          gjson.Get(resourceStr, "get_metadata_and_previews").Bool(),
+		PublicFullSize:  gjson.Get(resourceStr, "get_full_size").Bool(),
+		ResponsibleUser: gjson.Get(resourceStr, "responsible_user_id").Str,
+	}
+
+	// fetch subject permissions
+	for _, list := range []struct {
+		relation string
+		key      string
+		target   *[]*Permission
+	}{
+		{relation: "-user-permissions", key: "user_id", target: &perms.Users},
+		{relation: "-group-permissions", key: "group_id", target: &perms.Groups},
+		{relation: "-api-client-permissions", key: "api_client_id", target: &perms.APIClients},
+	} {
+		// get list url
+		listURL, err := c.Expand(string(kind)+list.relation, map[string]string{
+			"id": id,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// iterate permissions
+		pager := c.Paginate(listURL, string(kind)+list.relation, 0)
+		for pager.Next() {
+			item := pager.Item()
+			*list.target = append(*list.target, &Permission{
+				ID:              item.Get(list.key).Str,
+				View:            item.Get("get_metadata_and_previews").Bool(),
+				ViewFullSize:    item.Get("get_full_size").Bool(),
+				EditMetaData:    item.Get("edit_metadata").Bool() || item.Get("edit_metadata_and_relations").Bool(),
+				EditPermissions: item.Get("edit_permissions").Bool(),
+			})
+		}
+
+		// skip unreadable lists
+		err = pager.Error()
+		if errors.Is(err, ErrAccessForbidden) || errors.Is(err, ErrInvalidAuthentication) {
+			*list.target = nil
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return perms, nil
+}
+
+// Public will return whether the media entry is publicly accessible. If the
+// permissions have not been compiled, the media entry is assumed to be
+// accessible.
+func (e *MediaEntry) Public() bool {
+	return e.Permissions == nil || e.Permissions.Public
+}
+
+// PublicPreviews will return the previews of the media entry if they are
+// publicly accessible. If the permissions have not been compiled, the
+// previews are assumed to be accessible.
+func (e *MediaEntry) PublicPreviews() []*Preview {
+	// check permissions
+	if !e.Public() {
+		return nil
+	}
+
+	return e.Previews
+}
+
+// PublicFile will return whether the original file of the media entry is
+// publicly accessible. If the permissions have not been compiled, the file is
+// assumed to be accessible.
+func (e *MediaEntry) PublicFile() bool {
+	return e.Permissions == nil || e.Permissions.PublicFullSize
+}
@@ -0,0 +1,73 @@
+package madek
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissions(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/collections/c1/perms/resource"] = `{"get_metadata_and_previews": true, "responsible_user_id": "u1"}`
+	routes["/api/collections/c1/perms/users/?page=0"] = `{"collection-user-permissions": [{"user_id": "u2", "get_metadata_and_previews": true, "edit_metadata_and_relations": true}]}`
+	routes["/api/collections/c1/perms/groups/?page=0"] = `{"collection-group-permissions": []}`
+	routes["/api/collections/c1/perms/api-clients/?page=0"] = `{"collection-api-client-permissions": []}`
+	routes["/api/media-entries/e1/perms/resource"] = `{"get_metadata_and_previews": true, "get_full_size": true}`
+	routes["/api/media-entries/e1/perms/users/?page=0"] = `{"media-entry-user-permissions": []}`
+	routes["/api/media-entries/e1/perms/groups/?page=0"] = `{
+		"media-entry-group-permissions": [{"group_id": "g1", "get_metadata_and_previews": true, "get_full_size": true}],
+		"_json-roa": {"collection": {"next": {"href": "/api/media-entries/e1/perms/groups/?page=1"}}}
+	}`
+	routes["/api/media-entries/e1/perms/groups/?page=1"] = `{"media-entry-group-permissions": [{"group_id": "g2", "get_metadata_and_previews": true}]}`
+	routes["/api/media-entries/e1/perms/api-clients/?page=0"] = `{"media-entry-api-client-permissions": []}`
+	routes["/api/media-entries/e2/perms/resource"] = `{"get_metadata_and_previews": false}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/media-entries/e2/perms/users/":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/api/media-entries/e2/perms/groups/":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fakeHandler(routes).ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, "", "")
+
+	perms, err := client.GetPermissions(MediaEntryKind, "e1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Permissions{
+		Public:         true,
+		PublicFullSize: true,
+		Groups: []*Permission{
+			{ID: "g1", View: true, ViewFullSize: true},
+			{ID: "g2", View: true},
+		},
+	}, perms)
+
+	coll, err := client.CompileCollection("c1")
+	assert.NoError(t, err)
+	assert.Nil(t, coll.Permissions)
+	assert.Len(t, coll.MediaEntries[1].PublicPreviews(), 4)
+
+	routes["/api/media-entries/e2/perms/api-clients/?page=0"] = `{"media-entry-api-client-permissions": []}`
+
+	client.IncludePermissions = true
+	coll, err = client.CompileCollection("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Permissions{
+		Public:          true,
+		ResponsibleUser: "u1",
+		Users: []*Permission{
+			{ID: "u2", View: true, EditMetaData: true},
+		},
+	}, coll.Permissions)
+	assert.True(t, coll.MediaEntries[0].PublicFile())
+	assert.Len(t, coll.MediaEntries[0].PublicPreviews(), 3)
+	assert.False(t, coll.MediaEntries[1].PublicFile())
+	assert.Empty(t, coll.MediaEntries[1].PublicPreviews())
+	assert.Equal(t, &Permissions{}, coll.MediaEntries[1].Permissions)
+}
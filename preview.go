@@ -0,0 +1,91 @@
+package madek
+
+import (
+	"fmt"
+	"io"
+)
+
+// A PreviewFilter describes a wanted preview.
+type PreviewFilter struct {
+	// The media type e.g. "image" or "video".
+	Type string
+
+	// The content type e.g. "image/jpeg" or "video/webm".
+	ContentType string
+
+	// The minimal width and height. Zero values are ignored.
+	Width  int
+	Height int
+}
+
+// FindPreviews will return all previews that match the type and content type
+// of the provided filter.
+func (e *MediaEntry) FindPreviews(filter PreviewFilter) []*Preview {
+	// collect previews
+	var list []*Preview
+	for _, preview := range e.Previews {
+		if filter.Type != "" && preview.Type != filter.Type {
+			continue
+		}
+		if filter.ContentType != "" && preview.ContentType != filter.ContentType {
+			continue
+		}
+		list = append(list, preview)
+	}
+
+	return list
+}
+
+// SelectPreview will select the preview that best fits the provided filter.
+// This is the smallest matching preview that covers the requested dimensions
+// or the largest matching preview if none does. Nil is returned if no preview
+// matches the type and content type.
+func (e *MediaEntry) SelectPreview(filter PreviewFilter) *Preview {
+	// prepare candidates
+	var smallestCovering *Preview
+	var largest *Preview
+
+	for _, preview := range e.FindPreviews(filter) {
+		// track largest
+		if largest == nil || previewLess(largest, preview) {
+			largest = preview
+		}
+
+		// check dimensions
+		if preview.Width < filter.Width || preview.Height < filter.Height {
+			continue
+		}
+
+		// track smallest covering
+		if smallestCovering == nil || previewLess(preview, smallestCovering) {
+			smallestCovering = preview
+		}
+	}
+
+	// prefer covering preview
+	if smallestCovering != nil {
+		return smallestCovering
+	}
+
+	return largest
+}
+
+// DownloadPreview will download the provided preview to the provided writer.
+// Resumption and progress reporting work as with DownloadMediaFile.
+func (c *Client) DownloadPreview(preview *Preview, w io.Writer, progress ProgressFunc) error {
+	// check url
+	if preview.URL == "" {
+		return fmt.Errorf("missing url for preview: %s", preview.ID)
+	}
+
+	return c.download(preview.URL, w, 0, progress)
+}
+
+func previewLess(a, b *Preview) bool {
+	// compare areas
+	if a.Width*a.Height != b.Width*b.Height {
+		return a.Width*a.Height < b.Width*b.Height
+	}
+
+	return a.ID < b.ID
+}
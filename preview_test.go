@@ -0,0 +1,47 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var videoEntry = &MediaEntry{
+	ID: "44ab4b5b-4b3f-4854-9d5f-66a852a25eab",
+	Previews: []*Preview{
+		{ID: "32391c88", Type: "image", ContentType: "image/jpeg", Size: "maximum", Width: 1920, Height: 1078},
+		{ID: "34ce3a55", Type: "video", ContentType: "video/webm", Size: "large", Width: 620, Height: 348},
+		{ID: "62911c6b", Type: "video", ContentType: "video/mp4", Size: "large", Width: 1920, Height: 1080},
+		{ID: "80aa7fa8", Type: "image", ContentType: "image/jpeg", Size: "small", Width: 100, Height: 56},
+		{ID: "80e8b5b2", Type: "video", ContentType: "video/webm", Size: "large", Width: 1920, Height: 1080},
+		{ID: "a8d0dc69", Type: "image", ContentType: "image/jpeg", Size: "large", Width: 620, Height: 348},
+		{ID: "c83719e2", Type: "image", ContentType: "image/jpeg", Size: "large", Width: 620, Height: 348},
+		{ID: "cb2705a3", Type: "image", ContentType: "image/jpeg", Size: "medium", Width: 300, Height: 168},
+		{ID: "d16696a5", Type: "video", ContentType: "video/mp4", Size: "large", Width: 620, Height: 348},
+		{ID: "eacadcc3", Type: "image", ContentType: "image/jpeg", Size: "x_large", Width: 1024, Height: 575},
+		{ID: "ff312114", Type: "image", ContentType: "image/jpeg", Size: "small_125", Width: 125, Height: 70},
+	},
+}
+
+func TestSelectPreview(t *testing.T) {
+	assert.Len(t, videoEntry.FindPreviews(PreviewFilter{Type: "video"}), 4)
+	assert.Len(t, videoEntry.FindPreviews(PreviewFilter{ContentType: "video/webm"}), 2)
+
+	preview := videoEntry.SelectPreview(PreviewFilter{ContentType: "video/webm", Height: 1080})
+	assert.Equal(t, "80e8b5b2", preview.ID)
+
+	preview = videoEntry.SelectPreview(PreviewFilter{Type: "video", ContentType: "video/mp4", Width: 400})
+	assert.Equal(t, "d16696a5", preview.ID)
+
+	preview = videoEntry.SelectPreview(PreviewFilter{Type: "image", Width: 200})
+	assert.Equal(t, "cb2705a3", preview.ID)
+
+	preview = videoEntry.SelectPreview(PreviewFilter{Type: "image", Width: 600})
+	assert.Equal(t, "a8d0dc69", preview.ID)
+
+	preview = videoEntry.SelectPreview(PreviewFilter{Type: "image", Width: 4000})
+	assert.Equal(t, "32391c88", preview.ID)
+
+	preview = videoEntry.SelectPreview(PreviewFilter{Type: "audio"})
+	assert.Nil(t, preview)
+}
@@ -0,0 +1,192 @@
+package madek
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A RenditionGroup holds the previews of a media entry that share the same
+// media and content type, ordered by width.
+type RenditionGroup struct {
+	Type        string     `json:"type"`
+	ContentType string     `json:"content_type"`
+	Previews    []*Preview `json:"previews"`
+}
+
+// Codec will return the codec of the group derived from the content type
+// e.g. "mp4" or "webm".
+func (g *RenditionGroup) Codec() string {
+	// get subtype
+	if i := strings.IndexByte(g.ContentType, '/'); i >= 0 {
+		return g.ContentType[i+1:]
+	}
+
+	return g.ContentType
+}
+
+// Largest will return the widest preview of the group.
+func (g *RenditionGroup) Largest() *Preview {
+	// check previews
+	if len(g.Previews) == 0 {
+		return nil
+	}
+
+	return g.Previews[len(g.Previews)-1]
+}
+
+// SrcSet will return the previews as a value for the srcset attribute e.g.
+// "https://.../media/a 100w, https://.../media/b 300w".
+func (g *RenditionGroup) SrcSet() string {
+	// prepare candidates
+	list := make([]string, 0, len(g.Previews))
+	for _, preview := range g.Previews {
+		list = append(list, fmt.Sprintf("%s %dw", preview.URL, preview.Width))
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// Sizes will return a value for the sizes attribute that selects each preview
+// for viewports up to its width e.g. "(max-width: 100px) 100px, 300px".
+func (g *RenditionGroup) Sizes() string {
+	// prepare sizes
+	list := make([]string, 0, len(g.Previews))
+	for i, preview := range g.Previews {
+		if i == len(g.Previews)-1 {
+			list = append(list, fmt.Sprintf("%dpx", preview.Width))
+		} else {
+			list = append(list, fmt.Sprintf("(max-width: %dpx) %dpx", preview.Width, preview.Width))
+		}
+	}
+
+	return strings.Join(list, ", ")
+}
+
+// A Source describes a single source element of a video or audio element.
+type Source struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// A RenditionSet holds the rendition groups of a media entry ordered by media
+// type and content type.
+type RenditionSet []*RenditionGroup
+
+// Renditions will group the previews of the media entry by media and content
+// type. Duplicate previews with the same dimensions are dropped.
+func (e *MediaEntry) Renditions() RenditionSet {
+	// prepare set
+	var set RenditionSet
+	groups := map[string]*RenditionGroup{}
+	seen := map[string]bool{}
+
+	// copy and sort previews
+	previews := append([]*Preview(nil), e.Previews...)
+	sort.Slice(previews, func(i, j int) bool {
+		if previews[i].Width != previews[j].Width {
+			return previews[i].Width < previews[j].Width
+		}
+		return previewLess(previews[i], previews[j])
+	})
+
+	for _, preview := range previews {
+		// check duplicate
+		key := fmt.Sprintf("%s|%s|%dx%d", preview.Type, preview.ContentType, preview.Width, preview.Height)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// get or create group
+		group, ok := groups[preview.Type+"|"+preview.ContentType]
+		if !ok {
+			group = &RenditionGroup{
+				Type:        preview.Type,
+				ContentType: preview.ContentType,
+			}
+			groups[preview.Type+"|"+preview.ContentType] = group
+			set = append(set, group)
+		}
+
+		// add preview
+		group.Previews = append(group.Previews, preview)
+	}
+
+	// sort groups
+	sort.Slice(set, func(i, j int) bool {
+		if set[i].Type != set[j].Type {
+			return set[i].Type < set[j].Type
+		}
+		return set[i].ContentType < set[j].ContentType
+	})
+
+	return set
+}
+
+// Filter will return the groups of the specified media type.
+func (s RenditionSet) Filter(typ string) RenditionSet {
+	// collect groups
+	var list RenditionSet
+	for _, group := range s {
+		if group.Type == typ {
+			list = append(list, group)
+		}
+	}
+
+	return list
+}
+
+// Images will return the image groups.
+func (s RenditionSet) Images() RenditionSet {
+	return s.Filter("image")
+}
+
+// Videos will return the video groups.
+func (s RenditionSet) Videos() RenditionSet {
+	return s.Filter("video")
+}
+
+// Group will return the group with the specified content type.
+func (s RenditionSet) Group(contentType string) *RenditionGroup {
+	// find group
+	for _, group := range s {
+		if group.ContentType == contentType {
+			return group
+		}
+	}
+
+	return nil
+}
+
+// Sources will return one source per video and audio group that best fits
+// the provided width. The sources keep the order of the groups.
+func (s RenditionSet) Sources(width int) []Source {
+	// collect sources
+	var list []Source
+	for _, group := range s {
+		// skip images
+		if group.Type != "video" && group.Type != "audio" {
+			continue
+		}
+
+		// select preview
+		entry := MediaEntry{Previews: group.Previews}
+		preview := entry.SelectPreview(PreviewFilter{Width: width})
+		if preview == nil {
+			continue
+		}
+
+		// add source
+		list = append(list, Source{
+			URL:         preview.URL,
+			ContentType: preview.ContentType,
+			Width:       preview.Width,
+			Height:      preview.Height,
+		})
+	}
+
+	return list
+}
@@ -0,0 +1,42 @@
+package madek
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenditions(t *testing.T) {
+	entry := &MediaEntry{}
+	for _, preview := range videoEntry.Previews {
+		p := *preview
+		p.URL = "/media/" + p.ID
+		entry.Previews = append(entry.Previews, &p)
+	}
+
+	set := entry.Renditions()
+	assert.Len(t, set, 3)
+	assert.Len(t, set.Images(), 1)
+	assert.Len(t, set.Videos(), 2)
+
+	images := set.Group("image/jpeg")
+	assert.Equal(t, "jpeg", images.Codec())
+	assert.Len(t, images.Previews, 6)
+	assert.Equal(t, "32391c88", images.Largest().ID)
+	assert.Equal(t, "/media/80aa7fa8 100w, /media/ff312114 125w, /media/cb2705a3 300w, /media/a8d0dc69 620w, /media/eacadcc3 1024w, /media/32391c88 1920w", images.SrcSet())
+	assert.Equal(t, "(max-width: 100px) 100px, (max-width: 125px) 125px, (max-width: 300px) 300px, (max-width: 620px) 620px, (max-width: 1024px) 1024px, 1920px", images.Sizes())
+
+	videos := set.Videos()
+	assert.Equal(t, "mp4", videos[0].Codec())
+	assert.Equal(t, "webm", videos[1].Codec())
+
+	assert.Equal(t, []Source{
+		{URL: "/media/d16696a5", ContentType: "video/mp4", Width: 620, Height: 348},
+		{URL: "/media/34ce3a55", ContentType: "video/webm", Width: 620, Height: 348},
+	}, set.Sources(600))
+
+	assert.Equal(t, []Source{
+		{URL: "/media/62911c6b", ContentType: "video/mp4", Width: 1920, Height: 1080},
+		{URL: "/media/80e8b5b2", ContentType: "video/webm", Width: 1920, Height: 1080},
+	}, set.Sources(1920))
+}
@@ -0,0 +1,147 @@
+package madek
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidReference is returned when a reference cannot be parsed.
+var ErrInvalidReference = errors.New("invalid reference")
+
+// ErrForeignReference is returned when a reference points to another host
+// than the Madek instance of the client.
+var ErrForeignReference = errors.New("foreign reference")
+
+// A Reference identifies a resource on a Madek instance. The kind is empty if
+// the reference is a bare id. The host is only set for absolute URLs.
+type Reference struct {
+	Kind Kind
+	ID   string
+	Host string
+}
+
+// Local will return whether the reference has no host or the same host as the
+// provided address.
+func (r Reference) Local(address string) bool {
+	// check host
+	if r.Host == "" {
+		return true
+	}
+
+	// parse address
+	u, err := url.Parse(address)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(r.Host, u.Host)
+}
+
+// referenceSegments maps the path segments of web and API URLs to kinds.
+var referenceSegments = map[string]Kind{
+	"sets":          CollectionKind,
+	"collections":   CollectionKind,
+	"entries":       MediaEntryKind,
+	"media-entries": MediaEntryKind,
+	"people":        PersonKind,
+	"keyword":       KeywordKind,
+	"keywords":      KeywordKind,
+}
+
+// ParseReference will parse the provided string into a reference. Supported
+// are web URLs (e.g. "https://medienarchiv.zhdk.ch/sets/<id>" or
+// "/entries/<id>"), API URLs (e.g. "/api/media-entries/<id>"), custom URLs
+// of the form "madek:<kind>:<id>" or "urn:madek:<kind>:<id>" and bare ids.
+func ParseReference(str string) (Reference, error) {
+	// trim string
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return Reference{}, fmt.Errorf("%w: empty", ErrInvalidReference)
+	}
+
+	// handle custom urls
+	if custom := strings.TrimPrefix(str, "urn:"); strings.HasPrefix(custom, "madek:") {
+		parts := strings.Split(strings.TrimPrefix(custom, "madek:"), ":")
+		if len(parts) != 2 || parts[1] == "" {
+			return Reference{}, fmt.Errorf("%w: %s", ErrInvalidReference, str)
+		}
+		switch kind := Kind(parts[0]); kind {
+		case CollectionKind, MediaEntryKind, PersonKind, KeywordKind:
+			return Reference{Kind: kind, ID: parts[1]}, nil
+		default:
+			return Reference{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidReference, parts[0])
+		}
+	}
+
+	// handle bare ids
+	if !strings.ContainsAny(str, "/:?#") {
+		return Reference{ID: str}, nil
+	}
+
+	// parse url
+	u, err := url.Parse(str)
+	if err != nil {
+		return Reference{}, fmt.Errorf("%w: %s", ErrInvalidReference, str)
+	}
+
+	// find kind and id
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, segment := range segments {
+		if kind, ok := referenceSegments[segment]; ok && i+1 < len(segments) && segments[i+1] != "" {
+			return Reference{Kind: kind, ID: segments[i+1], Host: u.Host}, nil
+		}
+	}
+
+	return Reference{}, fmt.Errorf("%w: %s", ErrInvalidReference, str)
+}
+
+// CompileReference will compile the referenced resource. It returns a
+// *Collection, *MediaEntry, *Author or *Keyword depending on the kind of the
+// reference. Bare ids are compiled as media entries or, if not found, as
+// collections. References to other hosts than the address of the client are
+// rejected with ErrForeignReference.
+func (c *Client) CompileReference(ref Reference) (interface{}, error) {
+	// check host
+	if !ref.Local(c.address) {
+		return nil, fmt.Errorf("%w: %s", ErrForeignReference, ref.Host)
+	}
+
+	switch ref.Kind {
+	case CollectionKind:
+		return c.CompileCollection(ref.ID)
+	case MediaEntryKind:
+		return c.CompileMediaEntry(ref.ID)
+	case PersonKind:
+		return c.GetAuthor(ref.ID)
+	case KeywordKind:
+		term, err := c.GetKeywordTerm(ref.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &Keyword{ID: ref.ID, Term: term}, nil
+	case "":
+		entry, err := c.CompileMediaEntry(ref.ID)
+		if errors.Is(err, ErrNotFound) {
+			return c.CompileCollection(ref.ID)
+		} else if err != nil {
+			return nil, err
+		}
+		return entry, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidReference, ref.Kind)
+	}
+}
+
+// Resolve will parse the provided reference and compile the referenced
+// resource using CompileReference.
+func (c *Client) Resolve(str string) (interface{}, error) {
+	// parse reference
+	ref, err := ParseReference(str)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CompileReference(ref)
+}
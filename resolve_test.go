@@ -0,0 +1,93 @@
+package madek
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	for str, ref := range map[string]Reference{
+		"82fc2bd5-0b63-4aa1-a4b4-bd5ea1ddb1e1":              {ID: "82fc2bd5-0b63-4aa1-a4b4-bd5ea1ddb1e1"},
+		"https://medienarchiv.zhdk.ch/sets/c1":              {Kind: CollectionKind, ID: "c1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/sets/c1?tab=entries":  {Kind: CollectionKind, ID: "c1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/entries/e1/more_data": {Kind: MediaEntryKind, ID: "e1", Host: "medienarchiv.zhdk.ch"},
+		"/entries/e1":                                                  {Kind: MediaEntryKind, ID: "e1"},
+		"https://medienarchiv.zhdk.ch/people/a1":                       {Kind: PersonKind, ID: "a1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/vocabulary/keyword/k1":           {Kind: KeywordKind, ID: "k1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/api/collections/c1":              {Kind: CollectionKind, ID: "c1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/api/media-entries/e1/meta-data/": {Kind: MediaEntryKind, ID: "e1", Host: "medienarchiv.zhdk.ch"},
+		"https://medienarchiv.zhdk.ch/api/keywords/k1":                 {Kind: KeywordKind, ID: "k1", Host: "medienarchiv.zhdk.ch"},
+		"madek:collection:c1":                                          {Kind: CollectionKind, ID: "c1"},
+		"urn:madek:media-entry:e1":                                     {Kind: MediaEntryKind, ID: "e1"},
+		"madek:person:a1":                                              {Kind: PersonKind, ID: "a1"},
+	} {
+		ret, err := ParseReference(str)
+		assert.NoError(t, err, str)
+		assert.Equal(t, ref, ret, str)
+	}
+
+	for _, str := range []string{
+		"",
+		"https://medienarchiv.zhdk.ch/",
+		"https://medienarchiv.zhdk.ch/sets/",
+		"https://medienarchiv.zhdk.ch/my/dashboard",
+		"madek:foo:c1",
+		"madek:collection",
+		"urn:isbn:123",
+	} {
+		_, err := ParseReference(str)
+		assert.True(t, errors.Is(err, ErrInvalidReference), str)
+	}
+}
+
+func TestClientResolve(t *testing.T) {
+	server := fakeAPI(t, fakeMadek("/api/"))
+	client := NewClient(server.URL, "", "")
+
+	value, err := client.Resolve(server.URL + "/sets/c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", value.(*Collection).ID)
+	assert.Len(t, value.(*Collection).MediaEntries, 2)
+
+	value, err = client.Resolve("e1")
+	assert.NoError(t, err)
+	assert.Equal(t, "e1", value.(*MediaEntry).ID)
+
+	value, err = client.Resolve("c1")
+	assert.NoError(t, err)
+	assert.Equal(t, "c1", value.(*Collection).ID)
+
+	value, err = client.Resolve("madek:person:a1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Author{ID: "a1", FirstName: "Jane", LastName: "Doe"}, value)
+
+	value, err = client.Resolve("/vocabulary/keyword/k1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Keyword{ID: "k1", Term: "Design"}, value)
+
+	_, err = client.Resolve("x1")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = client.Resolve("https://medienarchiv.zhdk.ch/sets/c1")
+	assert.True(t, errors.Is(err, ErrForeignReference))
+}
+
+func TestReferenceLocal(t *testing.T) {
+	for _, item := range []struct {
+		ref     Reference
+		address string
+		local   bool
+	}{
+		{ref: Reference{ID: "c1"}, address: "https://medienarchiv.zhdk.ch", local: true},
+		{ref: Reference{ID: "c1", Host: "medienarchiv.zhdk.ch"}, address: "https://medienarchiv.zhdk.ch", local: true},
+		{ref: Reference{ID: "c1", Host: "Medienarchiv.ZHdK.ch"}, address: "https://medienarchiv.zhdk.ch/", local: true},
+		{ref: Reference{ID: "c1", Host: "localhost:8080"}, address: "http://localhost:8080", local: true},
+		{ref: Reference{ID: "c1", Host: "localhost:8081"}, address: "http://localhost:8080", local: false},
+		{ref: Reference{ID: "c1", Host: "evil.example.com"}, address: "https://medienarchiv.zhdk.ch", local: false},
+		{ref: Reference{ID: "c1", Host: "medienarchiv.zhdk.ch"}, address: "", local: false},
+	} {
+		assert.Equal(t, item.local, item.ref.Local(item.address), item.ref.Host+" "+item.address)
+	}
+}
@@ -0,0 +1,200 @@
+package madek
+
+import (
+	"strings"
+	"time"
+)
+
+// SchemaContext is the JSON-LD context of schema.org documents.
+const SchemaContext = "https://schema.org"
+
+// A SchemaThing is a schema.org item that is encoded as JSON-LD.
+type SchemaThing struct {
+	Context             string         `json:"@context,omitempty"`
+	Type                string         `json:"@type"`
+	ID                  string         `json:"@id,omitempty"`
+	Name                string         `json:"name,omitempty"`
+	GivenName           string         `json:"givenName,omitempty"`
+	FamilyName          string         `json:"familyName,omitempty"`
+	AlternateName       string         `json:"alternateName,omitempty"`
+	AlternativeHeadline string         `json:"alternativeHeadline,omitempty"`
+	Description         string         `json:"description,omitempty"`
+	URL                 string         `json:"url,omitempty"`
+	Identifier          string         `json:"identifier,omitempty"`
+	Creator             []*SchemaThing `json:"creator,omitempty"`
+	SourceOrganization  []*SchemaThing `json:"sourceOrganization,omitempty"`
+	Keywords            string         `json:"keywords,omitempty"`
+	Genre               []string       `json:"genre,omitempty"`
+	DateCreated         string         `json:"dateCreated,omitempty"`
+	DateModified        string         `json:"dateModified,omitempty"`
+	UploadDate          string         `json:"uploadDate,omitempty"`
+	CopyrightNotice     string         `json:"copyrightNotice,omitempty"`
+	License             string         `json:"license,omitempty"`
+	ContentURL          string         `json:"contentUrl,omitempty"`
+	EncodingFormat      string         `json:"encodingFormat,omitempty"`
+	Width               int            `json:"width,omitempty"`
+	Height              int            `json:"height,omitempty"`
+	ThumbnailURL        string         `json:"thumbnailUrl,omitempty"`
+	Encoding            []*SchemaThing `json:"encoding,omitempty"`
+	HasPart             []*SchemaThing `json:"hasPart,omitempty"`
+}
+
+// SchemaOrg will return the collection as a schema.org Collection with its
+// media entries as parts. The web pages on the Madek instance with the
+// provided address are used as URLs if the address is not empty.
+func (c *Collection) SchemaOrg(address string) *SchemaThing {
+	// prepare thing
+	thing := &SchemaThing{
+		Context:      SchemaContext,
+		Type:         "Collection",
+		Identifier:   c.ID,
+		DateModified: schemaTime(c.ModifiedAt()),
+	}
+
+	// add url
+	if address != "" {
+		thing.URL = WebURL(address, CollectionKind, c.ID)
+		thing.ID = thing.URL
+	}
+
+	// add meta data
+	applySchemaMetaData(thing, c.MetaData)
+
+	// add parts
+	for _, entry := range c.MediaEntries {
+		thing.HasPart = append(thing.HasPart, entry.schemaOrg(address))
+	}
+
+	return thing
+}
+
+// SchemaOrg will return the media entry as a schema.org VideoObject,
+// AudioObject or ImageObject depending on its previews, or a CreativeWork if
+// it has no public previews. The web page on the Madek instance with the
+// provided address is used as URL if the address is not empty.
+func (e *MediaEntry) SchemaOrg(address string) *SchemaThing {
+	// get thing
+	thing := e.schemaOrg(address)
+	thing.Context = SchemaContext
+
+	return thing
+}
+
+func (e *MediaEntry) schemaOrg(address string) *SchemaThing {
+	// prepare thing
+	thing := &SchemaThing{
+		Type:         "CreativeWork",
+		Identifier:   e.ID,
+		DateModified: schemaTime(e.ModifiedAt()),
+	}
+
+	// add url
+	if address != "" {
+		thing.URL = WebURL(address, MediaEntryKind, e.ID)
+		thing.ID = thing.URL
+	}
+
+	// add meta data
+	applySchemaMetaData(thing, e.MetaData)
+
+	// get renditions
+	var set RenditionSet
+	if previews := e.PublicPreviews(); len(previews) > 0 {
+		set = (&MediaEntry{Previews: previews}).Renditions()
+	}
+
+	// add thumbnail
+	if images := set.Images(); len(images) > 0 {
+		thumb := (&MediaEntry{Previews: images[0].Previews}).SelectPreview(PreviewFilter{Width: 300})
+		thing.ThumbnailURL = thumb.URL
+	}
+
+	// select media
+	var groups RenditionSet
+	switch {
+	case len(set.Videos()) > 0:
+		thing.Type = "VideoObject"
+		thing.UploadDate = schemaTime(e.CreatedAt)
+		groups = set.Videos()
+	case len(set.Filter("audio")) > 0:
+		thing.Type = "AudioObject"
+		thing.UploadDate = schemaTime(e.CreatedAt)
+		groups = set.Filter("audio")
+	case len(set.Images()) > 0:
+		thing.Type = "ImageObject"
+		groups = set.Images()
+	default:
+		return thing
+	}
+
+	// add media
+	for i, group := range groups {
+		preview := group.Largest()
+		if i == 0 {
+			thing.ContentURL = preview.URL
+			thing.EncodingFormat = preview.ContentType
+			thing.Width = preview.Width
+			thing.Height = preview.Height
+			continue
+		}
+		thing.Encoding = append(thing.Encoding, &SchemaThing{
+			Type:           "MediaObject",
+			ContentURL:     preview.URL,
+			EncodingFormat: preview.ContentType,
+			Width:          preview.Width,
+			Height:         preview.Height,
+		})
+	}
+
+	return thing
+}
+
+func applySchemaMetaData(thing *SchemaThing, md *MetaData) {
+	// check meta data
+	if md == nil {
+		return
+	}
+
+	// add texts
+	thing.Name = md.Title
+	thing.AlternativeHeadline = md.Subtitle
+	thing.Description = md.Description
+	thing.Keywords = strings.Join(md.Keywords, ", ")
+	thing.Genre = md.Genres
+	thing.DateCreated = md.Year
+	thing.CopyrightNotice = strings.Join(appendNonEmpty(nil, md.Copyright.Holder, md.Copyright.Usage), "; ")
+
+	// add license
+	thing.License = rightsURI(md.Copyright.Licenses)
+	if thing.License == "" && len(md.Copyright.Licenses) > 0 {
+		thing.License = md.Copyright.Licenses[0]
+	}
+
+	// add creators
+	for _, author := range md.Authors {
+		thing.Creator = append(thing.Creator, &SchemaThing{
+			Type:       "Person",
+			Name:       author.Name(),
+			GivenName:  author.FirstName,
+			FamilyName: author.LastName,
+		})
+	}
+
+	// add organizations
+	for _, group := range md.Affiliation {
+		thing.SourceOrganization = append(thing.SourceOrganization, &SchemaThing{
+			Type:          "Organization",
+			Name:          group.Name,
+			AlternateName: group.Pseudonym,
+		})
+	}
+}
+
+func schemaTime(t time.Time) string {
+	// check zero
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
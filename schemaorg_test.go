@@ -0,0 +1,115 @@
+package madek
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaOrg(t *testing.T) {
+	video := &MediaEntry{
+		ID:        "e2",
+		CreatedAt: time.Date(2016, 5, 25, 10, 49, 49, 0, time.UTC),
+		MetaData:  &MetaData{Title: "Video"},
+	}
+	for _, preview := range videoEntry.Previews {
+		p := *preview
+		p.URL = "https://madek.example.com/media/" + p.ID
+		video.Previews = append(video.Previews, &p)
+	}
+
+	coll := &Collection{
+		ID:        "c1",
+		CreatedAt: time.Date(2016, 5, 25, 9, 46, 40, 0, time.UTC),
+		MetaData: &MetaData{
+			Title:       "Collection",
+			Keywords:    []string{"Design", "Art"},
+			Authors:     []*Author{{ID: "a1", FirstName: "Jane", LastName: "Doe"}},
+			Affiliation: []*Group{{ID: "g1", Name: "Department", Pseudonym: "DEP"}},
+			Copyright: Copyright{
+				Holder:   "Holder",
+				Usage:    "Usage",
+				Licenses: []string{"CC-By-SA-CH: Attribution Share Alike"},
+			},
+		},
+		MediaEntries: []*MediaEntry{
+			{
+				ID:       "e1",
+				MetaData: &MetaData{Title: "Image", Year: "2016", Copyright: Copyright{Licenses: []string{"All rights reserved"}}},
+				Previews: []*Preview{
+					{ID: "p1", Type: "image", ContentType: "image/jpeg", Width: 100, Height: 56, URL: "https://madek.example.com/media/p1"},
+					{ID: "p2", Type: "image", ContentType: "image/jpeg", Width: 620, Height: 348, URL: "https://madek.example.com/media/p2"},
+				},
+			},
+			video,
+			{ID: "e3", Previews: video.Previews, Permissions: &Permissions{}},
+		},
+	}
+
+	thing := coll.SchemaOrg("https://madek.example.com")
+	data, err := json.MarshalIndent(thing, "", "  ")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "Collection",
+		"@id": "https://madek.example.com/sets/c1",
+		"name": "Collection",
+		"url": "https://madek.example.com/sets/c1",
+		"identifier": "c1",
+		"creator": [{"@type": "Person", "name": "Jane Doe", "givenName": "Jane", "familyName": "Doe"}],
+		"sourceOrganization": [{"@type": "Organization", "name": "Department", "alternateName": "DEP"}],
+		"keywords": "Design, Art",
+		"dateModified": "2016-05-25T09:46:40Z",
+		"copyrightNotice": "Holder; Usage",
+		"license": "CC-By-SA-CH: Attribution Share Alike",
+		"hasPart": [
+			{
+				"@type": "ImageObject",
+				"@id": "https://madek.example.com/entries/e1",
+				"name": "Image",
+				"url": "https://madek.example.com/entries/e1",
+				"identifier": "e1",
+				"dateCreated": "2016",
+				"license": "All rights reserved",
+				"contentUrl": "https://madek.example.com/media/p2",
+				"encodingFormat": "image/jpeg",
+				"width": 620,
+				"height": 348,
+				"thumbnailUrl": "https://madek.example.com/media/p2"
+			},
+			{
+				"@type": "VideoObject",
+				"@id": "https://madek.example.com/entries/e2",
+				"name": "Video",
+				"url": "https://madek.example.com/entries/e2",
+				"identifier": "e2",
+				"dateModified": "2016-05-25T10:49:49Z",
+				"uploadDate": "2016-05-25T10:49:49Z",
+				"contentUrl": "https://madek.example.com/media/62911c6b",
+				"encodingFormat": "video/mp4",
+				"width": 1920,
+				"height": 1080,
+				"thumbnailUrl": "https://madek.example.com/media/cb2705a3",
+				"encoding": [{
+					"@type": "MediaObject",
+					"contentUrl": "https://madek.example.com/media/80e8b5b2",
+					"encodingFormat": "video/webm",
+					"width": 1920,
+					"height": 1080
+				}]
+			},
+			{
+				"@type": "CreativeWork",
+				"@id": "https://madek.example.com/entries/e3",
+				"url": "https://madek.example.com/entries/e3",
+				"identifier": "e3"
+			}
+		]
+	}`, string(data))
+
+	entry := video.SchemaOrg("")
+	assert.Equal(t, SchemaContext, entry.Context)
+	assert.Empty(t, entry.URL)
+}
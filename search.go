@@ -0,0 +1,101 @@
+package madek
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// A Query describes a search for media entries.
+type Query struct {
+	// The full text to search for.
+	Text string
+
+	// The meta data values to match, keyed by meta key.
+	MetaData map[string]string
+
+	// The collection to search in.
+	Collection string
+
+	// The maximum number of results. Zero means no limit.
+	Limit int
+}
+
+// SearchMediaEntries will search and compile the media entries that match the
+// provided query. The media entries are returned in the order of the search
+// results.
+func (c *Client) SearchMediaEntries(query Query) ([]*MediaEntry, error) {
+	// prepare filter
+	filter := map[string]interface{}{}
+	if query.Text != "" {
+		filter["search"] = query.Text
+	}
+	if len(query.MetaData) > 0 {
+		var list []map[string]string
+		for key, value := range query.MetaData {
+			list = append(list, map[string]string{
+				"key":   key,
+				"match": value,
+			})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i]["key"] < list[j]["key"]
+		})
+		filter["meta_data"] = list
+	}
+
+	// encode filter
+	filterBy, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// get search url
+	params := map[string]string{}
+	if query.Collection != "" {
+		params["collection_id"] = query.Collection
+	}
+	url, err := c.Expand("media-entries", params)
+	if err != nil {
+		return nil, err
+	}
+
+	// set filter
+	url, err = setQuery(url, map[string]string{
+		"filter_by": string(filterBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// collect ids
+	var ids []string
+	pager := c.Paginate(url, "media-entries", 0)
+	for pager.Next() {
+		if query.Limit > 0 && len(ids) >= query.Limit {
+			break
+		}
+		ids = append(ids, pager.Item().Get("id").Str)
+	}
+	if pager.Error() != nil {
+		return nil, pager.Error()
+	}
+
+	// compile media entries
+	list, err := c.compileMediaEntries(ids, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// index media entries
+	index := map[string]*MediaEntry{}
+	for _, entry := range list {
+		index[entry.ID] = entry
+	}
+
+	// restore result order
+	for i, id := range ids {
+		list[i] = index[id]
+	}
+
+	return list, nil
+}
@@ -0,0 +1,41 @@
+package madek
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchMediaEntries(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/entries/?"+url.Values{
+		"collection_id": {"c1"},
+		"filter_by":     {`{"meta_data":[{"key":"madek_core:title","match":"Video"}],"search":"video"}`},
+		"page":          {"0"},
+	}.Encode()] = `{"media-entries": [{"id": "e2"}, {"id": "e1"}]}`
+	server := fakeAPI(t, routes)
+
+	client := NewClient(server.URL, "", "")
+
+	list, err := client.SearchMediaEntries(Query{
+		Text:       "video",
+		MetaData:   map[string]string{"madek_core:title": "Video"},
+		Collection: "c1",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "e2", list[0].ID)
+	assert.Equal(t, "Video", list[0].MetaData.Title)
+	assert.Equal(t, "e1", list[1].ID)
+
+	list, err = client.SearchMediaEntries(Query{
+		Text:       "video",
+		MetaData:   map[string]string{"madek_core:title": "Video"},
+		Collection: "c1",
+		Limit:      1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "e2", list[0].ID)
+}
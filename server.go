@@ -0,0 +1,683 @@
+package madek
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Server is a http.Handler that serves compiled collections and media
+// entries from a cache that is refreshed in the background. Preview and file
+// bytes are proxied using the credentials of the client, but only if they are
+// public. Media entries that are not public are not served and omitted from
+// collections. As resources are assumed public if their permissions have not
+// been compiled, the client should include permissions if it is
+// authenticated.
+// The following routes are served:
+//
+//	GET /collections/<id>         The compiled collection as JSON.
+//	GET /entries/<id>             The compiled media entry as JSON.
+//	GET /previews/<id>            The bytes of a preview.
+//	GET /files/<id>               The original file of a media entry.
+//	GET /feeds/<id>[.atom|.rss|.json]  The collection as a feed.
+//	GET /oai                      The collections over OAI-PMH.
+//	GET /oembed?url=<entry url>   The oEmbed response of a media entry.
+//
+// If collections are configured, only those collections and their media
+// entries are served. Otherwise, collections and media entries are compiled
+// and cached on their first request and the least recently compiled ones are
+// evicted if the cache size is exceeded.
+type Server struct {
+	// The client used to compile collections and media entries.
+	Client *Client
+
+	// The ids of the served collections.
+	Collections []string
+
+	// The interval at which cached collections and media entries are
+	// refreshed. Defaults to five minutes.
+	Interval time.Duration
+
+	// The public URL of the server used for feed and OAI-PMH URLs.
+	BaseURL string
+
+	// The name of the repository served over OAI-PMH.
+	RepositoryName string
+
+	// The maximum number of collections and the maximum number of media
+	// entries that are compiled on demand and cached. Defaults to 100.
+	CacheSize int
+
+	// The function that is called to report refreshes and errors.
+	Log func(msg string)
+
+	mutex       sync.RWMutex
+	collections map[string]*serverItem
+	entries     map[string]*serverItem
+	members     map[string]*serverItem
+	previews    map[string]*Preview
+	collOrder   []string
+	entryOrder  []string
+
+	callMutex sync.Mutex
+	calls     map[string]*serverCall
+}
+
+type serverItem struct {
+	value interface{}
+	data  []byte
+	etag  string
+}
+
+type serverCall struct {
+	wg   sync.WaitGroup
+	item *serverItem
+	err  error
+}
+
+// Load will compile and cache the configured collections.
+func (s *Server) Load() error {
+	for _, id := range s.Collections {
+		_, err := s.compileCollection(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run will refresh the cached collections and media entries at the
+// configured interval until the provided channel is closed. Errors are
+// reported using the log function.
+func (s *Server) Run(done <-chan struct{}) {
+	// get interval
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	// prepare ticker
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// errors are already logged
+			_ = s.Refresh()
+		case <-done:
+			return
+		}
+	}
+}
+
+// Refresh will refresh all cached collections and media entries. Collections
+// are refreshed using RefreshCollection which only recompiles their changed
+// media entries, while standalone media entries are recompiled. Failures are
+// logged and skipped, the first one is returned.
+func (s *Server) Refresh() error {
+	// get cached items
+	s.mutex.RLock()
+	var collections []*Collection
+	for _, item := range s.collections {
+		collections = append(collections, item.value.(*Collection))
+	}
+	var entries []string
+	for id := range s.entries {
+		entries = append(entries, id)
+	}
+	s.mutex.RUnlock()
+
+	// prepare error handler
+	var first error
+	fail := func(kind, id string, err error) {
+		s.log("refresh %s %s failed: %s", kind, id, err.Error())
+		if first == nil {
+			first = err
+		}
+	}
+
+	// refresh collections
+	for _, coll := range collections {
+		s.log("refresh collection %s", coll.ID)
+
+		// refresh a copy as the cached collection may be in use
+		fresh := *coll
+		err := s.Client.RefreshCollection(&fresh)
+		if err != nil {
+			fail("collection", coll.ID, err)
+			continue
+		}
+
+		// store collection
+		err = s.store(fresh.ID, &fresh)
+		if err != nil {
+			fail("collection", coll.ID, err)
+		}
+	}
+
+	// refresh media entries
+	for _, id := range entries {
+		s.log("refresh entry %s", id)
+
+		// compile entry
+		entry, err := s.Client.CompileMediaEntry(id)
+		if err != nil {
+			fail("entry", id, err)
+			continue
+		}
+
+		// store entry
+		err = s.store(id, entry)
+		if err != nil {
+			fail("entry", id, err)
+		}
+	}
+
+	return first
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// check method
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// split path
+	route, id := strings.Trim(r.URL.Path, "/"), ""
+	if i := strings.IndexByte(route, '/'); i >= 0 {
+		route, id = route[:i], route[i+1:]
+	}
+
+	// handle route
+	switch {
+	case route == "collections" && id != "":
+		s.serveItem(w, r, id, s.collection)
+	case route == "entries" && id != "":
+		s.serveItem(w, r, id, s.publicEntry)
+	case route == "previews" && id != "":
+		s.servePreview(w, r, id)
+	case route == "files" && id != "":
+		s.serveFile(w, r, id)
+	case route == "feeds" && id != "":
+		s.serveFeed(w, r, id)
+	case route == "oai" && id == "":
+		s.serveOAI(w, r)
+	case route == "oembed" && id == "":
+		s.serveOEmbed(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveItem(w http.ResponseWriter, r *http.Request, id string, get func(string) (*serverItem, error)) {
+	// get item
+	item, err := get(id)
+	if err != nil {
+		s.serveError(w, err)
+		return
+	}
+
+	// check etag
+	w.Header().Set("ETag", item.etag)
+	if r.Header.Get("If-None-Match") == item.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// write item
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(item.data)
+}
+
+func (s *Server) servePreview(w http.ResponseWriter, r *http.Request, id string) {
+	// get preview
+	s.mutex.RLock()
+	preview := s.previews[id]
+	s.mutex.RUnlock()
+	if preview == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// set headers as previews are immutable
+	w.Header().Set("Content-Type", preview.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	// check method
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// proxy preview
+	pw := &proxyWriter{writer: w}
+	err := s.Client.DownloadPreview(preview, pw, nil)
+	if err != nil && !pw.written {
+		s.serveError(w, err)
+	}
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, id string) {
+	// get entry
+	item, err := s.publicEntry(id)
+	if err != nil {
+		s.serveError(w, err)
+		return
+	}
+	entry := item.value.(*MediaEntry)
+
+	// check file
+	if entry.StreamURL == "" || !entry.PublicFile() {
+		http.NotFound(w, r)
+		return
+	}
+
+	// set headers
+	w.Header().Set("Content-Type", entry.FileType)
+	if entry.FileSize > 0 {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", entry.FileSize))
+	}
+	if entry.FileName != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": entry.FileName,
+		}))
+	}
+
+	// check method
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// proxy file
+	pw := &proxyWriter{writer: w}
+	err = s.Client.DownloadMediaFile(entry, pw, nil)
+	if err != nil && !pw.written {
+		w.Header().Del("Content-Length")
+		s.serveError(w, err)
+	}
+}
+
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request, name string) {
+	// get id
+	id := strings.TrimSuffix(name, path.Ext(name))
+
+	// prepare handler
+	handler := &FeedHandler{
+		Collection: func() (*Collection, error) {
+			item, err := s.collection(id)
+			if err != nil {
+				return nil, err
+			}
+			return item.value.(*Collection), nil
+		},
+		Generator: &FeedGenerator{
+			Address: s.Client.URL(""),
+		},
+	}
+	if s.BaseURL != "" {
+		handler.Generator.FeedURL = strings.TrimSuffix(s.BaseURL, "/") + r.URL.Path
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+func (s *Server) serveOAI(w http.ResponseWriter, r *http.Request) {
+	// prepare provider
+	provider := &OAIProvider{
+		Collections: func() ([]*Collection, error) {
+			s.mutex.RLock()
+			defer s.mutex.RUnlock()
+			var list []*Collection
+			for _, item := range s.collections {
+				list = append(list, item.value.(*Collection))
+			}
+			return list, nil
+		},
+		RepositoryName: s.RepositoryName,
+		BaseURL:        strings.TrimSuffix(s.BaseURL, "/") + "/oai",
+		Address:        s.Client.URL(""),
+	}
+	if provider.RepositoryName == "" {
+		provider.RepositoryName = "Madek"
+	}
+
+	provider.ServeHTTP(w, r)
+}
+
+func (s *Server) serveOEmbed(w http.ResponseWriter, r *http.Request) {
+	// get query
+	query := r.URL.Query()
+
+	// check format
+	if format := query.Get("format"); format != "" && format != "json" {
+		http.Error(w, "unsupported format", http.StatusNotImplemented)
+		return
+	}
+
+	// parse reference and check that it points to the instance or server
+	ref, err := ParseReference(query.Get("url"))
+	if err != nil || ref.Kind != MediaEntryKind || !ref.Local(s.Client.URL("")) && !ref.Local(s.BaseURL) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// parse dimensions
+	var dims [2]int
+	for i, key := range []string{"maxwidth", "maxheight"} {
+		if value := query.Get(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid "+key, http.StatusBadRequest)
+				return
+			}
+			dims[i] = n
+		}
+	}
+
+	// get entry
+	item, err := s.publicEntry(ref.ID)
+	if err != nil {
+		s.serveError(w, err)
+		return
+	}
+
+	// prepare response
+	res := item.value.(*MediaEntry).OEmbed(dims[0], dims[1])
+	res.ProviderName = s.RepositoryName
+	if res.ProviderName == "" {
+		res.ProviderName = "Madek"
+	}
+	res.ProviderURL = s.Client.URL("")
+
+	// write response
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (s *Server) serveError(w http.ResponseWriter, err error) {
+	// check not found
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	s.log("request failed: %s", err.Error())
+
+	http.Error(w, "bad gateway", http.StatusBadGateway)
+}
+
+func (s *Server) collection(id string) (*serverItem, error) {
+	// check cache
+	s.mutex.RLock()
+	item := s.collections[id]
+	s.mutex.RUnlock()
+	if item != nil {
+		return item, nil
+	}
+
+	// check restriction
+	if len(s.Collections) > 0 && !stringInList(s.Collections, id) {
+		return nil, ErrNotFound
+	}
+
+	return s.compileCollection(id)
+}
+
+func (s *Server) entry(id string) (*serverItem, error) {
+	// check cache
+	s.mutex.RLock()
+	item := s.members[id]
+	if item == nil {
+		item = s.entries[id]
+	}
+	s.mutex.RUnlock()
+	if item != nil {
+		return item, nil
+	}
+
+	// check restriction
+	if len(s.Collections) > 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.do("entry/"+id, func() (*serverItem, error) {
+		s.log("compile entry %s", id)
+
+		// compile entry
+		entry, err := s.Client.CompileMediaEntry(id)
+		if err != nil {
+			return nil, err
+		}
+
+		// store entry
+		err = s.store(id, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		// get item
+		s.mutex.RLock()
+		item := s.entries[id]
+		s.mutex.RUnlock()
+
+		return item, nil
+	})
+}
+
+func (s *Server) publicEntry(id string) (*serverItem, error) {
+	// get entry
+	item, err := s.entry(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// check permissions
+	if !item.value.(*MediaEntry).Public() {
+		return nil, ErrNotFound
+	}
+
+	return item, nil
+}
+
+func (s *Server) compileCollection(id string) (*serverItem, error) {
+	return s.do("collection/"+id, func() (*serverItem, error) {
+		s.log("compile collection %s", id)
+
+		// compile collection
+		coll, err := s.Client.CompileCollection(id)
+		if err != nil {
+			return nil, err
+		}
+
+		// store collection
+		err = s.store(id, coll)
+		if err != nil {
+			return nil, err
+		}
+
+		// get item
+		s.mutex.RLock()
+		item := s.collections[id]
+		s.mutex.RUnlock()
+
+		return item, nil
+	})
+}
+
+func (s *Server) do(key string, fn func() (*serverItem, error)) (*serverItem, error) {
+	// acquire mutex
+	s.callMutex.Lock()
+
+	// await pending call
+	if call := s.calls[key]; call != nil {
+		s.callMutex.Unlock()
+		call.wg.Wait()
+		return call.item, call.err
+	}
+
+	// register call
+	call := &serverCall{}
+	call.wg.Add(1)
+	if s.calls == nil {
+		s.calls = map[string]*serverCall{}
+	}
+	s.calls[key] = call
+	s.callMutex.Unlock()
+
+	// perform call
+	call.item, call.err = fn()
+	call.wg.Done()
+
+	// remove call
+	s.callMutex.Lock()
+	delete(s.calls, key)
+	s.callMutex.Unlock()
+
+	return call.item, call.err
+}
+
+func (s *Server) store(id string, value interface{}) error {
+	// prepare item
+	item, err := newServerItem(value)
+	if err != nil {
+		return err
+	}
+
+	// acquire mutex
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// ensure maps
+	if s.collections == nil {
+		s.collections = map[string]*serverItem{}
+		s.entries = map[string]*serverItem{}
+		s.previews = map[string]*Preview{}
+	}
+
+	// store standalone media entry
+	if _, ok := value.(*MediaEntry); ok {
+		s.entries[id] = item
+		s.entryOrder = s.evict(s.entries, s.entryOrder, id)
+		s.rebuildPreviews()
+		return nil
+	}
+
+	// store collection
+	s.collections[id] = item
+	if len(s.Collections) == 0 {
+		s.collOrder = s.evict(s.collections, s.collOrder, id)
+	}
+
+	// rebuild members and reuse items of unchanged media entries
+	members := map[string]*serverItem{}
+	for _, item := range s.collections {
+		for _, entry := range item.value.(*Collection).MediaEntries {
+			if old := s.members[entry.ID]; old != nil && old.value == entry {
+				members[entry.ID] = old
+				continue
+			}
+			members[entry.ID], err = newServerItem(entry)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	s.members = members
+
+	// rebuild previews
+	s.rebuildPreviews()
+
+	return nil
+}
+
+func (s *Server) evict(cache map[string]*serverItem, order []string, id string) []string {
+	// track new items
+	if !stringInList(order, id) {
+		order = append(order, id)
+	}
+
+	// get size
+	size := s.CacheSize
+	if size <= 0 {
+		size = 100
+	}
+
+	// remove oldest items
+	for len(order) > size {
+		delete(cache, order[0])
+		order = order[1:]
+	}
+
+	return order
+}
+
+func (s *Server) rebuildPreviews() {
+	// collect public previews of all cached media entries
+	s.previews = map[string]*Preview{}
+	for _, items := range []map[string]*serverItem{s.members, s.entries} {
+		for _, item := range items {
+			for _, preview := range item.value.(*MediaEntry).PublicPreviews() {
+				s.previews[preview.ID] = preview
+			}
+		}
+	}
+}
+
+func newServerItem(value interface{}) (*serverItem, error) {
+	// only expose the public media entries of collections
+	exposed := value
+	if coll, ok := value.(*Collection); ok {
+		public := *coll
+		public.MediaEntries = nil
+		for _, entry := range coll.MediaEntries {
+			if entry.Public() {
+				public.MediaEntries = append(public.MediaEntries, entry)
+			}
+		}
+		exposed = &public
+	}
+
+	// encode value
+	data, err := json.Marshal(exposed)
+	if err != nil {
+		return nil, err
+	}
+
+	// compute fingerprint
+	fingerprint, err := Fingerprint(exposed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverItem{
+		value: value,
+		data:  data,
+		etag:  `"` + fingerprint + `"`,
+	}, nil
+}
+
+func (s *Server) log(format string, args ...interface{}) {
+	if s.Log != nil {
+		s.Log(fmt.Sprintf(format, args...))
+	}
+}
+
+type proxyWriter struct {
+	writer  http.ResponseWriter
+	written bool
+}
+
+func (w *proxyWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return w.writer.Write(p)
+}
@@ -0,0 +1,272 @@
+package madek
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/files/f1/data-stream"] = "image-bytes"
+	routes["/media/p2"] = "preview"
+
+	api := fakeAPI(t, routes)
+	client := NewClient(api.URL, "", "")
+
+	server := &Server{
+		Client:      client,
+		Collections: []string{"c1"},
+		BaseURL:     "https://example.com",
+	}
+
+	err := server.Load()
+	assert.NoError(t, err)
+
+	request := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("/collections/c1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"id":"c1"`)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rec = request("/collections/c1", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	err = server.Refresh()
+	assert.NoError(t, err)
+
+	rec = request("/collections/c1", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	rec = request("/collections/c2", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/entries/e1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":"e1"`)
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+
+	rec = request("/entries/e9", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/previews/p2", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "preview", rec.Body.String())
+
+	rec = request("/previews/p3", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/files/e1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=image.jpg`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, "image-bytes", rec.Body.String())
+
+	rec = request("/files/e2", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/feeds/c1.rss", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "<title>Image</title>")
+
+	rec = request("/oai?verb=ListSets", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<setSpec>c1</setSpec>")
+
+	rec = request("/oembed?url="+url.QueryEscape(api.URL+"/entries/e1")+"&maxwidth=400", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"type": "photo",
+		"version": "1.0",
+		"title": "Image",
+		"provider_name": "Madek",
+		"provider_url": "`+api.URL+`",
+		"thumbnail_url": "`+api.URL+`/media/p1",
+		"thumbnail_width": 100,
+		"thumbnail_height": 56,
+		"url": "`+api.URL+`/media/p1",
+		"width": 100,
+		"height": 56
+	}`, rec.Body.String())
+
+	rec = request("/oembed?url=https%3A%2F%2Fexample.com%2Fentries%2Fe1", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = request("/oembed?url=https%3A%2F%2Fevil.example.com%2Fentries%2Fe1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/oembed?url=https%3A%2F%2Fmadek.example.com%2Fentries%2Fe1&format=xml", "")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	rec = request("/oembed?url=https%3A%2F%2Fmadek.example.com%2Fsets%2Fc1", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = request("/foo", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServerOnDemand(t *testing.T) {
+	api := fakeAPI(t, fakeMadek("/api/"))
+	client := NewClient(api.URL, "", "")
+
+	server := &Server{
+		Client: client,
+	}
+
+	req := httptest.NewRequest("GET", "/entries/e2", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":"e2"`)
+
+	req = httptest.NewRequest("GET", "/collections/c1", nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":"c1"`)
+
+	req = httptest.NewRequest("GET", "/collections/c2", nil)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	err := server.Refresh()
+	assert.NoError(t, err)
+}
+
+func TestServerPermissions(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/files/f1/data-stream"] = "image-bytes"
+	routes["/media/p2"] = "preview"
+	routes["/media/p4"] = "preview"
+	for _, id := range []string{"e1", "e2"} {
+		routes["/api/media-entries/"+id+"/perms/users/?page=0"] = `{}`
+		routes["/api/media-entries/"+id+"/perms/groups/?page=0"] = `{}`
+		routes["/api/media-entries/"+id+"/perms/api-clients/?page=0"] = `{}`
+	}
+	routes["/api/media-entries/e1/perms/resource"] = `{"get_metadata_and_previews": false}`
+	routes["/api/media-entries/e2/perms/resource"] = `{"get_metadata_and_previews": true}`
+	routes["/api/collections/c1/perms/resource"] = `{"get_metadata_and_previews": true}`
+	routes["/api/collections/c1/perms/users/?page=0"] = `{}`
+	routes["/api/collections/c1/perms/groups/?page=0"] = `{}`
+	routes["/api/collections/c1/perms/api-clients/?page=0"] = `{}`
+
+	api := fakeAPI(t, routes)
+	client := NewClient(api.URL, "", "")
+	client.IncludePermissions = true
+
+	server := &Server{
+		Client: client,
+	}
+
+	request := func(path string) int {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, request("/entries/e1"))
+	assert.Equal(t, http.StatusNotFound, request("/previews/p2"))
+	assert.Equal(t, http.StatusNotFound, request("/files/e1"))
+	assert.Equal(t, http.StatusNotFound, request("/oembed?url="+url.QueryEscape(api.URL+"/entries/e1")))
+
+	assert.Equal(t, http.StatusOK, request("/entries/e2"))
+	assert.Equal(t, http.StatusOK, request("/previews/p4"))
+	assert.Equal(t, http.StatusOK, request("/oembed?url="+url.QueryEscape(api.URL+"/entries/e2")))
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/collections/c1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"id":"e2"`)
+	assert.NotContains(t, rec.Body.String(), `"id":"e1"`)
+	assert.NotContains(t, rec.Body.String(), `"Image"`)
+
+	assert.Equal(t, http.StatusNotFound, request("/entries/e1"))
+}
+
+func TestServerCache(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/api/sets/c2"] = routes["/api/sets/c1"]
+	routes["/api/entries/?collection_id=c2&page=0"] = `{"media-entries": [{"id": "e2"}]}`
+
+	var mutex sync.Mutex
+	requests := map[string]int{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		mutex.Unlock()
+		fakeHandler(routes).ServeHTTP(w, r)
+	}))
+	defer api.Close()
+
+	var log []string
+	server := &Server{
+		Client:    NewClient(api.URL, "", ""),
+		CacheSize: 1,
+		Log: func(msg string) {
+			mutex.Lock()
+			log = append(log, msg)
+			mutex.Unlock()
+		},
+	}
+
+	request := func(path string) int {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, request("/entries/e1"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, requests["/api/entries/e1"])
+
+	assert.Equal(t, http.StatusOK, request("/entries/e2"))
+	assert.Equal(t, http.StatusOK, request("/collections/c1"))
+	assert.Equal(t, http.StatusOK, request("/collections/c2"))
+
+	server.mutex.RLock()
+	assert.Len(t, server.entries, 1)
+	assert.NotNil(t, server.entries["e2"])
+	assert.Len(t, server.collections, 1)
+	assert.NotNil(t, server.collections["c2"])
+	assert.Len(t, server.members, 1)
+	assert.Nil(t, server.previews["p1"])
+	assert.NotNil(t, server.previews["p4"])
+	server.mutex.RUnlock()
+
+	delete(routes, "/api/sets/c2")
+	log = nil
+
+	err := server.Refresh()
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Equal(t, []string{
+		"refresh collection c2",
+		"refresh collection c2 failed: not found",
+		"refresh entry e2",
+	}, log)
+}
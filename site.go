@@ -0,0 +1,304 @@
+package madek
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed site/*.html
+var siteFiles embed.FS
+
+// SiteTemplates will return the default templates of the static site. The
+// "index.html" and "entry.html" templates render the pages and use the shared
+// "head", "foot", "style" and "media" templates. Individual templates may be
+// overridden by parsing replacements into the returned set.
+func SiteTemplates() (*template.Template, error) {
+	return template.New("site").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).ParseFS(siteFiles, "site/*.html")
+}
+
+// A SitePage is the data passed to the site templates. The index page has no
+// entry set.
+type SitePage struct {
+	Title      string
+	SiteTitle  string
+	Collection *Collection
+	Entries    []*SiteEntry
+	Entry      *SiteEntry
+	Previous   *SiteEntry
+	Next       *SiteEntry
+}
+
+// A SiteEntry is a media entry prepared for rendering. The preview URLs are
+// relative to the site directory if previews have been copied.
+type SiteEntry struct {
+	MediaEntry  *MediaEntry
+	Path        string
+	Title       string
+	Description string
+	Authors     []string
+	Year        string
+	Copyright   string
+	License     string
+	WebURL      string
+	Thumbnail   *Preview
+	Image       *RenditionGroup
+	Sources     []Source
+}
+
+// A SiteGenerator renders a compiled collection as a self-contained static
+// HTML gallery with an index page and one page per media entry.
+type SiteGenerator struct {
+	// The client used to download previews. Only required if previews are
+	// copied.
+	Client *Client
+
+	// The output directory.
+	Directory string
+
+	// The address of the Madek instance used to link the web pages of the
+	// media entries. Links are omitted if empty.
+	Address string
+
+	// The templates used to render the pages. Defaults to SiteTemplates.
+	Templates *template.Template
+
+	// Whether previews should be copied to the site directory instead of
+	// being linked. In both cases only public previews are used.
+	CopyPreviews bool
+
+	// The function that is called to report progress.
+	Log func(msg string)
+}
+
+// Generate will render the provided collection to the output directory.
+func (g *SiteGenerator) Generate(coll *Collection) error {
+	// get templates
+	tmpl := g.Templates
+	if tmpl == nil {
+		var err error
+		tmpl, err = SiteTemplates()
+		if err != nil {
+			return err
+		}
+	}
+
+	// ensure directory
+	err := os.MkdirAll(g.Directory, 0755)
+	if err != nil {
+		return err
+	}
+
+	// get title
+	title := coll.ID
+	if coll.MetaData != nil && coll.MetaData.Title != "" {
+		title = coll.MetaData.Title
+	}
+
+	// prepare entries
+	entries := make([]*SiteEntry, 0, len(coll.MediaEntries))
+	for _, entry := range coll.MediaEntries {
+		siteEntry, err := g.prepare(entry)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, siteEntry)
+	}
+
+	// render index
+	err = g.render(tmpl, "index.html", "index.html", &SitePage{
+		Title:      title,
+		SiteTitle:  title,
+		Collection: coll,
+		Entries:    entries,
+	})
+	if err != nil {
+		return err
+	}
+
+	// render entries
+	for i, entry := range entries {
+		page := &SitePage{
+			Title:      entry.Title,
+			SiteTitle:  title,
+			Collection: coll,
+			Entries:    entries,
+			Entry:      entry,
+		}
+		if i > 0 {
+			page.Previous = entries[i-1]
+		}
+		if i < len(entries)-1 {
+			page.Next = entries[i+1]
+		}
+		err = g.render(tmpl, "entry.html", entry.Path, page)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *SiteGenerator) prepare(entry *MediaEntry) (*SiteEntry, error) {
+	// prepare entry
+	siteEntry := &SiteEntry{
+		MediaEntry: entry,
+		Path:       entry.ID + ".html",
+		Title:      entry.ID,
+	}
+
+	// add meta data
+	if md := entry.MetaData; md != nil {
+		if md.Title != "" {
+			siteEntry.Title = md.Title
+		}
+		siteEntry.Description = md.Description
+		siteEntry.Authors = authorNames(md.Authors)
+		siteEntry.Year = md.Year
+		siteEntry.Copyright = strings.Join(appendNonEmpty(nil, md.Copyright.Holder, md.Copyright.Usage), "; ")
+		siteEntry.License = strings.Join(md.Copyright.Licenses, ", ")
+	}
+
+	// add web url
+	if g.Address != "" {
+		siteEntry.WebURL = WebURL(g.Address, MediaEntryKind, entry.ID)
+	}
+
+	// get public previews
+	previews := entry.PublicPreviews()
+
+	// select media
+	g.selectMedia(siteEntry, previews)
+
+	// check copy
+	if !g.CopyPreviews {
+		return siteEntry, nil
+	}
+
+	// collect selected previews
+	var selected []*Preview
+	if siteEntry.Thumbnail != nil {
+		selected = append(selected, siteEntry.Thumbnail)
+	}
+	if siteEntry.Image != nil {
+		selected = append(selected, siteEntry.Image.Previews...)
+	}
+	for _, source := range siteEntry.Sources {
+		for _, preview := range previews {
+			if preview.URL == source.URL {
+				selected = append(selected, preview)
+			}
+		}
+	}
+
+	// copy previews
+	paths := map[string]string{}
+	for _, preview := range selected {
+		if paths[preview.ID] == "" {
+			path, err := g.copy(preview)
+			if err != nil {
+				return nil, err
+			}
+			paths[preview.ID] = path
+		}
+	}
+
+	// link copied previews
+	local := make([]*Preview, 0, len(previews))
+	for _, preview := range previews {
+		if path, ok := paths[preview.ID]; ok {
+			copied := *preview
+			copied.URL = path
+			preview = &copied
+		}
+		local = append(local, preview)
+	}
+
+	// reselect media
+	g.selectMedia(siteEntry, local)
+
+	return siteEntry, nil
+}
+
+func (g *SiteGenerator) selectMedia(siteEntry *SiteEntry, previews []*Preview) {
+	// get renditions
+	set := (&MediaEntry{Previews: previews}).Renditions()
+
+	// select image and thumbnail
+	siteEntry.Image = nil
+	siteEntry.Thumbnail = nil
+	if images := set.Images(); len(images) > 0 {
+		siteEntry.Image = images[0]
+		siteEntry.Thumbnail = (&MediaEntry{Previews: images[0].Previews}).SelectPreview(PreviewFilter{Width: 300})
+	}
+
+	// select sources
+	siteEntry.Sources = set.Sources(1280)
+}
+
+func (g *SiteGenerator) copy(preview *Preview) (string, error) {
+	// get path
+	path := "previews/" + preview.ID + extensionFor(preview.ContentType)
+	full := filepath.Join(g.Directory, filepath.FromSlash(path))
+
+	// skip existing files as previews are immutable
+	if _, err := os.Stat(full); err == nil {
+		return path, nil
+	}
+
+	g.log("download %s", path)
+
+	// ensure directory
+	err := os.MkdirAll(filepath.Dir(full), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// open partial file
+	file, err := os.OpenFile(full+".part", os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	// download
+	err = g.Client.DownloadPreview(preview, file, nil)
+	if err != nil {
+		_ = file.Close()
+		return "", err
+	}
+
+	// close file
+	err = file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return path, os.Rename(full+".part", full)
+}
+
+func (g *SiteGenerator) render(tmpl *template.Template, name, path string, page *SitePage) error {
+	g.log("render %s", path)
+
+	// execute template
+	var buf bytes.Buffer
+	err := tmpl.ExecuteTemplate(&buf, name, page)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(g.Directory, path), buf.Bytes(), 0644)
+}
+
+func (g *SiteGenerator) log(format string, args ...interface{}) {
+	if g.Log != nil {
+		g.Log(fmt.Sprintf(format, args...))
+	}
+}
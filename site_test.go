@@ -0,0 +1,116 @@
+package madek
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiteGenerator(t *testing.T) {
+	routes := fakeMadek("/api/")
+	routes["/media/p1"] = "preview"
+	routes["/media/p2"] = "preview"
+	routes["/media/p4"] = "preview"
+	routes["/media/p6"] = "preview"
+	routes["/media/p7"] = "preview"
+
+	server := fakeAPI(t, routes)
+	client := NewClient(server.URL, "", "")
+
+	coll, err := client.CompileCollection("c1")
+	assert.NoError(t, err)
+
+	var log []string
+	site := &SiteGenerator{
+		Client:       client,
+		Directory:    t.TempDir(),
+		Address:      "https://madek.example.com",
+		CopyPreviews: true,
+		Log: func(msg string) {
+			log = append(log, msg)
+		},
+	}
+
+	err = site.Generate(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"download previews/p2.jpg",
+		"download previews/p1.jpg",
+		"download previews/p4.jpg",
+		"download previews/p7.mp4",
+		"download previews/p6.webm",
+		"render index.html",
+		"render e1.html",
+		"render e2.html",
+	}, log)
+
+	data, err := ioutil.ReadFile(filepath.Join(site.Directory, "previews", "p2.jpg"))
+	assert.NoError(t, err)
+	assert.Equal(t, "preview", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(site.Directory, "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<title>Collection</title>")
+	assert.Contains(t, string(data), `<a href="e1.html">`)
+	assert.Contains(t, string(data), `<img src="previews/p2.jpg" width="620" height="348"`)
+	assert.Contains(t, string(data), `<span>Video</span>`)
+
+	data, err = ioutil.ReadFile(filepath.Join(site.Directory, "e1.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "<h1>Image</h1>")
+	assert.Contains(t, string(data), `srcset="previews/p1.jpg 100w, previews/p2.jpg 620w"`)
+	assert.Contains(t, string(data), "<dd>Holder</dd>")
+	assert.Contains(t, string(data), `<a href="https://madek.example.com/entries/e1">`)
+	assert.Contains(t, string(data), `<a href="e2.html">Video &rarr;</a>`)
+
+	data, err = ioutil.ReadFile(filepath.Join(site.Directory, "e2.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `poster="previews/p4.jpg"`)
+	assert.Contains(t, string(data), `<source src="previews/p7.mp4" type="video/mp4">`)
+	assert.Contains(t, string(data), `<source src="previews/p6.webm" type="video/webm">`)
+
+	tmpl, err := SiteTemplates()
+	assert.NoError(t, err)
+	_, err = tmpl.New("index.html").Parse(`{{range .Entries}}{{.Title}};{{end}}`)
+	assert.NoError(t, err)
+
+	site = &SiteGenerator{
+		Directory: t.TempDir(),
+		Templates: tmpl,
+	}
+
+	err = site.Generate(coll)
+	assert.NoError(t, err)
+
+	data, err = ioutil.ReadFile(filepath.Join(site.Directory, "index.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Image;Video;", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(site.Directory, "e1.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `src="`+server.URL+`/media/p2"`)
+
+	coll.MediaEntries[1].Permissions = &Permissions{}
+	log = nil
+
+	site = &SiteGenerator{
+		Client:       client,
+		Directory:    t.TempDir(),
+		CopyPreviews: true,
+		Log: func(msg string) {
+			log = append(log, msg)
+		},
+	}
+
+	err = site.Generate(coll)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"download previews/p2.jpg",
+		"download previews/p1.jpg",
+		"render index.html",
+		"render e1.html",
+		"render e2.html",
+	}, log)
+}
@@ -0,0 +1,189 @@
+package madek
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+
+	"github.com/tidwall/gjson"
+)
+
+// An Upload describes a file that is uploaded as a new media entry.
+type Upload struct {
+	// The name of the file.
+	FileName string
+
+	// The content type of the file. If empty, it is derived from the file
+	// name.
+	ContentType string
+
+	// The size of the file if known. It is only used to report progress.
+	Size int64
+
+	// The reader that provides the file data.
+	Body io.Reader
+
+	// The optional collection the media entry is added to.
+	Collection string
+
+	// Whether the media entry should be published.
+	Publish bool
+
+	// The function that is called to report progress.
+	Progress ProgressFunc
+}
+
+// CreateMediaEntry will stream the provided upload to the API, optionally add
+// the created media entry to a collection and publish it and return it
+// compiled. Failures are returned as a RequestError that wraps
+// ErrQuotaExceeded, ErrAccessForbidden or ErrValidationFailed. If adding or
+// publishing fails, the created media entry is returned together with the
+// error.
+func (c *Client) CreateMediaEntry(upload Upload) (*MediaEntry, error) {
+	// check body
+	if upload.Body == nil || upload.FileName == "" {
+		return nil, fmt.Errorf("missing file name or body")
+	}
+
+	// get content type
+	contentType := upload.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(upload.FileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// get url
+	url, err := c.Expand("media-entries", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare multipart stream
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	// write form concurrently
+	go func() {
+		// prepare header
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     "file",
+			"filename": upload.FileName,
+		}))
+		header.Set("Content-Type", contentType)
+
+		// create part
+		part, err := form.CreatePart(header)
+		if err != nil {
+			_ = writer.CloseWithError(err)
+			return
+		}
+
+		// copy data
+		_, err = io.Copy(&progressWriter{
+			writer:   part,
+			total:    upload.Size,
+			progress: upload.Progress,
+		}, upload.Body)
+		if err != nil {
+			_ = writer.CloseWithError(err)
+			return
+		}
+
+		// finish form
+		_ = writer.CloseWithError(form.Close())
+	}()
+
+	// send upload
+	res, err := c.Send("POST", url, form.FormDataContentType(), reader)
+	_ = reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// get id
+	id := gjson.Get(res, "id").Str
+	if id == "" {
+		return nil, fmt.Errorf("missing id of created media entry")
+	}
+
+	// add to collection
+	if upload.Collection != "" {
+		err = c.AddToCollection(upload.Collection, id)
+		if err != nil {
+			return c.createdMediaEntry(id), err
+		}
+	}
+
+	// publish media entry
+	if upload.Publish {
+		err = c.PublishMediaEntry(id)
+		if err != nil {
+			return c.createdMediaEntry(id), err
+		}
+	}
+
+	return c.CompileMediaEntry(id)
+}
+
+func (c *Client) createdMediaEntry(id string) *MediaEntry {
+	// compile media entry
+	entry, err := c.CompileMediaEntry(id)
+	if err != nil {
+		return &MediaEntry{ID: id}
+	}
+
+	return entry
+}
+
+// UploadFile will create a media entry from the file at the specified path
+// using CreateMediaEntry.
+func (c *Client) UploadFile(path, collection string, publish bool, progress ProgressFunc) (*MediaEntry, error) {
+	// open file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure close
+	defer file.Close()
+
+	// get size
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CreateMediaEntry(Upload{
+		FileName:   filepath.Base(path),
+		Size:       info.Size(),
+		Body:       file,
+		Collection: collection,
+		Publish:    publish,
+		Progress:   progress,
+	})
+}
+
+// PublishMediaEntry will publish the specified media entry.
+func (c *Client) PublishMediaEntry(id string) error {
+	// get url
+	url, err := c.Expand("media-entry", map[string]string{
+		"id": id,
+	})
+	if err != nil {
+		return err
+	}
+
+	// publish entry
+	_, err = c.SendJSON("PATCH", url, map[string]interface{}{
+		"is_published": true,
+	})
+
+	return err
+}
@@ -0,0 +1,124 @@
+package madek
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMediaEntry(t *testing.T) {
+	routes := fakeMadek("/api/")
+
+	var uploaded, added, published string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/entries/":
+			file, header, err := r.FormFile("file")
+			assert.NoError(t, err)
+			data, _ := ioutil.ReadAll(file)
+			uploaded = header.Filename + ":" + header.Header.Get("Content-Type") + ":" + string(data)
+			switch string(data) {
+			case "huge":
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				_, _ = w.Write([]byte(`{"message": "quota exhausted"}`))
+				return
+			case "private":
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message": "not allowed"}`))
+				return
+			case "invalid":
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"message": "invalid file"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": "e1"}`))
+		case r.Method == "POST" && r.URL.Path == "/api/collection-media-entry-arcs/":
+			data, _ := ioutil.ReadAll(r.Body)
+			added = string(data)
+			if strings.Contains(added, "c2") {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message": "not allowed"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": "arc1"}`))
+		case r.Method == "PATCH" && r.URL.Path == "/api/entries/e1":
+			data, _ := ioutil.ReadAll(r.Body)
+			published = string(data)
+			if strings.Contains(uploaded, "draft") {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"message": "missing title"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": "e1"}`))
+		default:
+			fakeHandler(routes).ServeHTTP(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", "")
+
+	var done int64
+	entry, err := client.CreateMediaEntry(Upload{
+		FileName:   "image.jpg",
+		Size:       5,
+		Body:       strings.NewReader("image"),
+		Collection: "c1",
+		Publish:    true,
+		Progress: func(n, total int64) {
+			done = n
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "e1", entry.ID)
+	assert.Equal(t, "Image", entry.MetaData.Title)
+	assert.Equal(t, "image.jpg:image/jpeg:image", uploaded)
+	assert.Equal(t, `{"collection_id":"c1","media_entry_id":"e1"}`, added)
+	assert.Equal(t, `{"is_published":true}`, published)
+	assert.Equal(t, int64(5), done)
+
+	_, err = client.CreateMediaEntry(Upload{
+		FileName: "video.mp4",
+		Body:     strings.NewReader("huge"),
+	})
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(t, "quota exceeded: quota exhausted (413)", err.Error())
+
+	_, err = client.CreateMediaEntry(Upload{
+		FileName: "video.mp4",
+		Body:     strings.NewReader("private"),
+	})
+	assert.True(t, errors.Is(err, ErrAccessForbidden))
+
+	_, err = client.CreateMediaEntry(Upload{
+		FileName: "video.mp4",
+		Body:     strings.NewReader("invalid"),
+	})
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+
+	entry, err = client.CreateMediaEntry(Upload{
+		FileName: "draft.jpg",
+		Body:     strings.NewReader("draft"),
+		Publish:  true,
+	})
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+	assert.Equal(t, "e1", entry.ID)
+	assert.Equal(t, "Image", entry.MetaData.Title)
+
+	published = ""
+	entry, err = client.CreateMediaEntry(Upload{
+		FileName:   "image.jpg",
+		Body:       strings.NewReader("image"),
+		Collection: "c2",
+		Publish:    true,
+	})
+	assert.True(t, errors.Is(err, ErrAccessForbidden))
+	assert.Equal(t, "e1", entry.ID)
+	assert.Equal(t, `{"collection_id":"c2","media_entry_id":"e1"}`, added)
+	assert.Empty(t, published)
+}